@@ -0,0 +1,101 @@
+package startup
+
+import (
+	"hash/fnv"
+	"math/rand/v2"
+	"sync"
+)
+
+// Balancer chooses the instance of a service that Connect connects to, when it is given a service name
+// rather than the ID of an Identity.  The instances are the healthy members of the group named by the service
+// that accept Connection requests.
+type Balancer interface {
+	// Pick returns the ID of the chosen instance.  instances is never empty, and is ordered by ID.
+	// key is the value specified by WithConnectKey, if any
+	Pick(service, key string, instances []Registration) string
+}
+
+// NewRoundRobinBalancer returns a Balancer that chooses each instance of a service in turn
+func NewRoundRobinBalancer() Balancer {
+	return &roundRobin{next: map[string]int{}}
+}
+
+type roundRobin struct {
+	lck  sync.Mutex
+	next map[string]int
+}
+
+func (b *roundRobin) Pick(service, key string, instances []Registration) string {
+	b.lck.Lock()
+	defer b.lck.Unlock()
+
+	i := b.next[service] % len(instances)
+	b.next[service] = i + 1
+	return instances[i].ID
+}
+
+// defaultBalancer returns the round-robin Balancer shared by the Connects using the DiscoveryService,
+// or a new round-robin Balancer if the DiscoveryService was not created by NewDiscoveryService
+func defaultBalancer(d DiscoveryService) Balancer {
+	if d, ok := d.(*ds); ok {
+		return d.balancer
+	}
+	return NewRoundRobinBalancer()
+}
+
+// NewRandomBalancer returns a Balancer that chooses an instance of a service at random
+func NewRandomBalancer() Balancer {
+	return randomBalancer{}
+}
+
+type randomBalancer struct{}
+
+func (randomBalancer) Pick(service, key string, instances []Registration) string {
+	return instances[rand.N(len(instances))].ID
+}
+
+// NewLeastOutstandingBalancer returns a Balancer that chooses the instance of a service with the fewest
+// outstanding requests, choosing at random between instances that are equally loaded
+func NewLeastOutstandingBalancer() Balancer {
+	return leastOutstanding{}
+}
+
+type leastOutstanding struct{}
+
+func (leastOutstanding) Pick(service, key string, instances []Registration) string {
+	var least []Registration
+	for _, r := range instances {
+		switch {
+		case len(least) == 0 || r.Outstanding < least[0].Outstanding:
+			least = append(least[:0], r)
+		case r.Outstanding == least[0].Outstanding:
+			least = append(least, r)
+		}
+	}
+	return least[rand.N(len(least))].ID
+}
+
+// NewConsistentHashBalancer returns a Balancer that chooses the instance of a service using the key specified
+// by WithConnectKey, so that the same key is consistently connected to the same instance whilst it remains
+// available.  Should instances come or go, only the keys of the affected instances move.
+func NewConsistentHashBalancer() Balancer {
+	return consistentHash{}
+}
+
+type consistentHash struct{}
+
+// Pick uses rendezvous hashing, choosing the instance with the highest hash of the key and its ID
+func (consistentHash) Pick(service, key string, instances []Registration) string {
+	var best string
+	var bestScore uint64
+	for _, r := range instances {
+		h := fnv.New64a()
+		h.Write([]byte(key))
+		h.Write([]byte{0})
+		h.Write([]byte(r.ID))
+		if score := h.Sum64(); len(best) == 0 || score > bestScore {
+			best, bestScore = r.ID, score
+		}
+	}
+	return best
+}
@@ -0,0 +1,166 @@
+package startup
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+func ExampleNewConsistentHashBalancer() {
+
+	handler := func(ctx context.Context, req *Req, res *Res) {
+		res.Status = Success
+	}
+	worker := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		<-ctx.Done()
+	}
+	client := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		var ids []string
+		for range 3 {
+			c, err := opts.Identity.Connect(ctx, "Sessions",
+				WithConnectDiscoveryService(opts.DiscoveryService),
+				WithConnectBalancer(NewConsistentHashBalancer()),
+				WithConnectKey("user-42"))
+			if err != nil {
+				panic(err)
+			}
+			ids = append(ids, c.ID)
+		}
+		fmt.Println(ids[0] == ids[1] && ids[1] == ids[2])
+	}
+
+	StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{Name: "Sessions", Func: worker, Handler: handler, Replicas: 3},
+		{Name: "Client", Func: client, RegisterWithDiscoveryService: true},
+	})
+	// Output:
+	// true
+}
+
+// instances returns Registrations with the specified IDs
+func instances(ids ...string) []Registration {
+	var regs []Registration
+	for _, id := range ids {
+		regs = append(regs, Registration{ID: id, Healthy: true})
+	}
+	return regs
+}
+
+func TestRoundRobinBalancer(t *testing.T) {
+
+	b := NewRoundRobinBalancer()
+	regs := instances("A", "B", "C")
+
+	var got []string
+	for range 4 {
+		got = append(got, b.Pick("S", "", regs))
+	}
+	if !slices.Equal(got, []string{"A", "B", "C", "A"}) {
+		t.Fatalf("expected instances in turn, got: %v", got)
+	}
+
+	// Services are balanced independently
+	if id := b.Pick("T", "", regs); id != "A" {
+		t.Fatalf("expected A, got: %s", id)
+	}
+}
+
+func TestRandomBalancer(t *testing.T) {
+
+	b := NewRandomBalancer()
+	regs := instances("A", "B")
+
+	seen := map[string]bool{}
+	for range 100 {
+		seen[b.Pick("S", "", regs)] = true
+	}
+	if len(seen) != 2 {
+		t.Fatalf("expected both instances to be chosen, got: %v", seen)
+	}
+}
+
+func TestLeastOutstandingBalancer(t *testing.T) {
+
+	b := NewLeastOutstandingBalancer()
+	regs := instances("A", "B", "C")
+	regs[0].Outstanding = 3
+	regs[1].Outstanding = 1
+	regs[2].Outstanding = 2
+
+	for range 10 {
+		if id := b.Pick("S", "", regs); id != "B" {
+			t.Fatalf("expected B, got: %s", id)
+		}
+	}
+}
+
+func TestConsistentHashBalancer(t *testing.T) {
+
+	b := NewConsistentHashBalancer()
+	all := instances("A", "B", "C", "D")
+
+	before := map[string]string{}
+	for i := range 100 {
+		key := fmt.Sprintf("key-%d", i)
+		before[key] = b.Pick("S", key, all)
+		if id := b.Pick("S", key, all); id != before[key] {
+			t.Fatalf("expected %s to be consistently chosen for %s, got: %s", before[key], key, id)
+		}
+	}
+
+	// Removing an instance only moves the keys of that instance
+	remaining := instances("A", "B", "D")
+	for key, id := range before {
+		if after := b.Pick("S", key, remaining); id != "C" && after != id {
+			t.Fatalf("expected %s to remain on %s, got: %s", key, id, after)
+		}
+	}
+}
+
+func TestConnect_Service(t *testing.T) {
+
+	handler := func(ctx context.Context, req *Req, res *Res) {
+		res.Status = Success
+	}
+	worker := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		<-ctx.Done()
+	}
+
+	got := make(chan []string, 1)
+	client := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		opts.DiscoveryService.SetHealth("Blue", false)
+
+		// The default Balancer is shared by the Connects using the DiscoveryService
+		var ids []string
+		for range 4 {
+			c, err := opts.Identity.Connect(ctx, "Resizer", WithConnectDiscoveryService(opts.DiscoveryService),
+				WithConnectTimeout(time.Second))
+			if err != nil {
+				panic(err)
+			}
+			ids = append(ids, c.ID)
+		}
+		if _, err := opts.Identity.Connect(ctx, "Unknown", WithConnectDiscoveryService(opts.DiscoveryService)); err != ErrIDNotFound {
+			panic(err)
+		}
+		got <- ids
+	}
+
+	err := StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{Name: "Red", Service: "Resizer", Func: worker, Handler: handler},
+		{Name: "Green", Service: "Resizer", Func: worker, Handler: handler},
+		{Name: "Blue", Service: "Resizer", Func: worker, Handler: handler},
+		{Name: "Grey", Service: "Resizer", Func: worker, RegisterWithDiscoveryService: true},
+		{Name: "Client", Func: client, RegisterWithDiscoveryService: true},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Blue is unhealthy and Grey has no Handler, so Green and Red are chosen in turn
+	if ids := <-got; !slices.Equal(ids, []string{"Green", "Red", "Green", "Red"}) {
+		t.Fatalf("expected healthy instances to be chosen in turn, got: %v", ids)
+	}
+}
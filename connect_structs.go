@@ -4,6 +4,8 @@ import "time"
 
 // Connection is returned by the Remote when a Requestor connects to it
 type Connection struct {
+	// ID is the id of the Remote, which is the chosen instance when connecting to a service
+	ID string
 	// ReqChan is the chan that the Remote indicates subsequent requests should be made to, for this Requestor
 	ReqChan chan<- *ReqWithChan
 	// Timeout is the duration after which the Connection will be dropped by the Remote
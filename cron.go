@@ -0,0 +1,169 @@
+package startup
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCron is raised if a cron expression cannot be parsed
+var ErrInvalidCron = errors.New("invalid cron expression")
+
+// cronSchedule is a parsed cron expression, with a bit set for each permitted value of each field
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// As for cron, if both the day of month and day of week are restricted, then a day matching either will do
+	domStar, dowStar bool
+}
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = map[string]int{
+	"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+	"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+}
+
+var dayNames = map[string]int{
+	"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+}
+
+// parseCron parses a standard five field cron expression (minute, hour, day of month, month
+// and day of week), supporting lists, ranges, steps, month and day names, and the descriptors
+// @yearly, @annually, @monthly, @weekly, @daily, @midnight and @hourly
+func parseCron(expr string) (*cronSchedule, error) {
+	expr = strings.TrimSpace(expr)
+	if d, ok := cronDescriptors[strings.ToLower(expr)]; ok {
+		expr = d
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%w: %q must have 5 fields", ErrInvalidCron, expr)
+	}
+
+	var c cronSchedule
+	var err error
+
+	if c.minute, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, err
+	}
+	if c.hour, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return nil, err
+	}
+	if c.dom, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return nil, err
+	}
+	if c.month, err = parseCronField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, err
+	}
+	if c.dow, err = parseCronField(fields[4], 0, 7, dayNames); err != nil {
+		return nil, err
+	}
+
+	// Sunday may be specified as either 0 or 7
+	if c.dow&(1<<7) != 0 {
+		c.dow = c.dow&^(1<<7) | 1
+	}
+
+	c.domStar = strings.HasPrefix(fields[2], "*")
+	c.dowStar = strings.HasPrefix(fields[4], "*")
+
+	return &c, nil
+}
+
+// parseCronField returns the bit set of the values permitted by a comma separated list
+// of values, ranges and steps, each of which must be within [lo,hi]
+func parseCronField(field string, lo, hi int, names map[string]int) (uint64, error) {
+	parseValue := func(s string) (int, error) {
+		if v, ok := names[strings.ToUpper(s)]; ok {
+			return v, nil
+		}
+		v, err := strconv.Atoi(s)
+		if err != nil || v < lo || v > hi {
+			return 0, fmt.Errorf("%w: %q is not in the range %d-%d", ErrInvalidCron, s, lo, hi)
+		}
+		return v, nil
+	}
+
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepStr); err != nil || step <= 0 {
+				return 0, fmt.Errorf("%w: invalid step in %q", ErrInvalidCron, part)
+			}
+		}
+
+		first, last := lo, hi
+		if rng != "*" {
+			from, to, isRange := strings.Cut(rng, "-")
+
+			var err error
+			if first, err = parseValue(from); err != nil {
+				return 0, err
+			}
+			switch {
+			case isRange:
+				if last, err = parseValue(to); err != nil {
+					return 0, err
+				}
+			case !hasStep:
+				last = first // A single value, whereas with a step, the range continues to hi
+			}
+			if first > last {
+				return 0, fmt.Errorf("%w: invalid range %q", ErrInvalidCron, rng)
+			}
+		}
+
+		for v := first; v <= last; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// next returns the first time after t that matches the cron expression, in the location of t,
+// or the zero time if there is no match within five years
+func (c *cronSchedule) next(t time.Time) time.Time {
+	loc := t.Location()
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<t.Month()) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case c.hour&(1<<t.Hour()) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case c.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (c *cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := c.dom&(1<<t.Day()) != 0
+	dowMatch := c.dow&(1<<t.Weekday()) != 0
+	if c.domStar || c.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
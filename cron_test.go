@@ -0,0 +1,60 @@
+package startup
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseCron_Next(t *testing.T) {
+
+	// Wednesday 15 January 2025, 10:30
+	from := time.Date(2025, time.January, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2025, time.January, 15, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2025, time.January, 15, 10, 45, 0, 0, time.UTC)},
+		{"5/20 * * * *", time.Date(2025, time.January, 15, 10, 45, 0, 0, time.UTC)},
+		{"0 9-17 * * MON-FRI", time.Date(2025, time.January, 15, 11, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2025, time.January, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 * SAT", time.Date(2025, time.January, 18, 0, 0, 0, 0, time.UTC)},
+		{"30 6 29 feb *", time.Date(2028, time.February, 29, 6, 30, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2025, time.January, 15, 11, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		c, err := parseCron(test.expr)
+		if err != nil {
+			t.Fatalf("unexpected error parsing %q: %v", test.expr, err)
+		}
+		if next := c.next(from); !next.Equal(test.expected) {
+			t.Fatalf("expected %q to next be due at %v, got: %v", test.expr, test.expected, next)
+		}
+	}
+}
+
+func TestParseCron_Never(t *testing.T) {
+
+	c, err := parseCron("0 0 31 FEB *")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if next := c.next(time.Now()); !next.IsZero() {
+		t.Fatalf("expected no match, got: %v", next)
+	}
+}
+
+func TestParseCron_Invalid(t *testing.T) {
+
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *",
+		"* * * * 8", "*/0 * * * *", "10-5 * * * *", "x * * * *", "@fortnightly"} {
+		if _, err := parseCron(expr); !errors.Is(err, ErrInvalidCron) {
+			t.Fatalf("Expected error: ErrInvalidCron for %q, got: %v", expr, err)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package startup
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrUnknownDependency is raised if a FunctionDeclaration depends on a StartableFunction that is not declared
+var ErrUnknownDependency = errors.New("unknown dependency")
+
+// ErrDependencyCycle is raised if the dependencies of the FunctionDeclarations form a cycle
+var ErrDependencyCycle = errors.New("dependency cycle detected")
+
+// ErrDependencyExited is raised if a StartableFunction exits before declaring itself ready,
+// so that the StartableFunctions depending on it cannot start
+var ErrDependencyExited = errors.New("dependency exited before it was ready")
+
+// ErrStartupTimeout is raised if the dependencies of a StartableFunction are not ready within
+// the startup timeout
+var ErrStartupTimeout = errors.New("timed out waiting for dependencies to be ready")
+
+// orderByDependencies validates the dependencies of the FunctionDeclarations, returning them
+// ordered so that each appears after all of the FunctionDeclarations it depends on.  Otherwise
+// the declared order is preserved.
+func orderByDependencies(fns []FunctionDeclaration) ([]FunctionDeclaration, error) {
+	index := make(map[string]int, len(fns))
+	for i, fn := range fns {
+		index[fn.Name] = i
+	}
+
+	pending := make([]int, len(fns))      // count of unordered dependencies of each FunctionDeclaration
+	dependents := make([][]int, len(fns)) // indices of the FunctionDeclarations depending on each
+	for i, fn := range fns {
+		for _, dep := range fn.DependsOn {
+			j, ok := index[dep]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s depends on %s", ErrUnknownDependency, fn.Name, dep)
+			}
+			pending[i]++
+			dependents[j] = append(dependents[j], i)
+		}
+	}
+
+	ordered := make([]FunctionDeclaration, 0, len(fns))
+	added := make([]bool, len(fns))
+
+	// Repeatedly take the first FunctionDeclaration, in declared order, whose dependencies are all ordered
+	for len(ordered) < len(fns) {
+		next := -1
+		for i := range fns {
+			if !added[i] && pending[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			var cycle []string
+			for i, fn := range fns {
+				if !added[i] {
+					cycle = append(cycle, fn.Name)
+				}
+			}
+			return nil, fmt.Errorf("%w between %v", ErrDependencyCycle, cycle)
+		}
+
+		added[next] = true
+		ordered = append(ordered, fns[next])
+		for _, i := range dependents[next] {
+			pending[i]--
+		}
+	}
+
+	return ordered, nil
+}
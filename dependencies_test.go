@@ -0,0 +1,146 @@
+package startup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func ExampleFunctionOptions_Ready() {
+
+	database := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		fmt.Println("database connected")
+		opts.Ready()
+		<-ctx.Done()
+	}
+
+	api := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		fmt.Println("api serving")
+	}
+
+	// API is declared first, but will only start once Database is ready
+	StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{Name: "API", Func: api, DependsOn: []string{"Database"}},
+		{Name: "Database", Func: database},
+	})
+
+	// Output:
+	// database connected
+	// api serving
+}
+
+func TestOrderByDependencies(t *testing.T) {
+
+	noop := func(ctx context.Context, opts *FunctionOptions, args ...any) {}
+
+	ordered, err := orderByDependencies([]FunctionDeclaration{
+		{Name: "A", Func: noop, DependsOn: []string{"C"}},
+		{Name: "B", Func: noop},
+		{Name: "C", Func: noop, DependsOn: []string{"B"}},
+		{Name: "D", Func: noop},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var names string
+	for _, fn := range ordered {
+		names += fn.Name
+	}
+	if names != "BCAD" {
+		t.Fatalf("expected order BCAD, got: %s", names)
+	}
+}
+
+func TestStartNamedFunctions_DependencyErrors(t *testing.T) {
+
+	noop := func(ctx context.Context, opts *FunctionOptions, args ...any) {}
+
+	tests := []struct {
+		name     string
+		funcs    []FunctionDeclaration
+		expected error
+	}{
+		{
+			"Unknown",
+			[]FunctionDeclaration{{Name: "A", Func: noop, DependsOn: []string{"Missing"}}},
+			ErrUnknownDependency,
+		},
+		{
+			"Self",
+			[]FunctionDeclaration{{Name: "A", Func: noop, DependsOn: []string{"A"}}},
+			ErrDependencyCycle,
+		},
+		{
+			"Cycle",
+			[]FunctionDeclaration{
+				{Name: "A", Func: noop, DependsOn: []string{"C"}},
+				{Name: "B", Func: noop, DependsOn: []string{"A"}},
+				{Name: "C", Func: noop, DependsOn: []string{"B"}},
+			},
+			ErrDependencyCycle,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := StartNamedFunctions(context.Background(), test.funcs)
+			if !errors.Is(err, test.expected) {
+				t.Fatalf("Expected error: %v, got: %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestStartNamedFunctions_StartupTimeout(t *testing.T) {
+
+	var started bool
+
+	neverReady := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		<-ctx.Done()
+	}
+	dependent := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		started = true
+	}
+
+	err := StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{Name: "NeverReady", Func: neverReady},
+		{Name: "Dependent", Func: dependent, DependsOn: []string{"NeverReady"}},
+	}, WithStartupTimeout(10*time.Millisecond))
+
+	if !errors.Is(err, ErrStartupTimeout) {
+		t.Fatalf("Expected error: ErrStartupTimeout, got: %v", err)
+	}
+	if started {
+		t.Fatal("expected Dependent not to start")
+	}
+}
+
+func TestRuntime_DependencyExited(t *testing.T) {
+
+	notReady := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		<-ctx.Done()
+	}
+	dependent := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		<-ctx.Done()
+	}
+
+	r, err := Start(context.Background(), []FunctionDeclaration{
+		{Name: "NotReady", Func: notReady},
+		{Name: "Dependent", Func: dependent, DependsOn: []string{"NotReady"}},
+	}, WithStartupTimeout(time.Minute))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Stopping the dependency before it is ready means Dependent can never start
+	if err := r.Stop("NotReady"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := r.Wait(); !errors.Is(err, ErrDependencyExited) {
+		t.Fatalf("Expected error: ErrDependencyExited, got: %v", err)
+	}
+}
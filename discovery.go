@@ -1,28 +1,80 @@
 package startup
 
 import (
+	"context"
 	"errors"
+	"slices"
 	"sync"
+	"time"
 )
 
 // DiscoveryService provides a mechanism for registry and discovery of Identities
 type DiscoveryService interface {
-	// Register allows Identities to be declared.  An error is raised if the Identity is already declared
+	// Register allows Identities to be declared.  An error is raised if the Identity is already declared.
+	// If the Identity is Leased, then its registration expires unless renewed within its TTL
 	Register(id Identity) error
+	// Deregister removes a declared Identity, so that it can no longer be found.  An error is raised if the Identity is not declared
+	Deregister(id string) error
+	// Renew extends the lease of a declared Identity by its TTL, without notifying watchers.  An error is raised
+	// if the Identity is not declared, including if its lease has already expired
+	Renew(id string) error
 	// Find allows the Location of a given ID to be retrieved, for subsequent Connection attempts
 	Find(id string) (Location, error)
+	// FindGroup returns the IDs of the Identities registered as members of the group, in order
+	FindGroup(group string) ([]string, error)
+	// SetHealth records whether a declared Identity is healthy (Identities are healthy when declared).
+	// An error is raised if the Identity is not declared
+	SetHealth(id string, healthy bool) error
+	// Watch returns a chan of the changes to the registrations that pass the filter (or all registrations,
+	// if the filter is nil), beginning with a RegistrationAdded event for each current registration.
+	// Events are queued, rather than dropped, for slow receivers.  The chan is closed once the context is Done,
+	// or should more than 1024 events be queued, in which case Watch must be called again to resynchronise.
+	// The filter is called from the goroutine delivering the events, so it may call the DiscoveryService
+	Watch(ctx context.Context, filter func(Registration) bool) (<-chan RegistrationEvent, error)
+	// List returns the current registrations, ordered by ID
+	List() []Registration
+	// Query returns the current registrations selected by the Query, ordered by ID
+	Query(q Query) []Registration
+	// SetMetadata replaces the Metadata of a declared Identity.  An error is raised if the Identity is not declared
+	SetMetadata(id string, m Metadata) error
+}
+
+// Leased is implemented by Identities whose registration with the DiscoveryService
+// expires unless it is renewed
+type Leased interface {
+	// TTL returns the duration of the lease, or 0 if the registration does not expire
+	TTL() time.Duration
 }
 
 // NewDiscoveryService returns an empty instance of DiscoveryService
 func NewDiscoveryService() DiscoveryService {
 	return &ds{
-		m: map[string]Identity{},
+		m:        map[string]*registration{},
+		watchers: map[*watcher]struct{}{},
+		balancer: NewRoundRobinBalancer(),
 	}
 }
 
 type ds struct {
-	m   map[string]Identity
-	lck sync.Mutex
+	m        map[string]*registration
+	watchers map[*watcher]struct{}
+	lck      sync.Mutex
+	balancer Balancer // The default Balancer of the Connects using the DiscoveryService
+}
+
+// registration records a registered Identity, with the expiry of its lease, if any
+type registration struct {
+	id      Identity
+	meta    Metadata
+	healthy bool
+	ttl     time.Duration
+	expires time.Time
+	timer   *time.Timer // Evicts the registration once its lease expires
+}
+
+// expired returns true if the lease of the registration has expired
+func (r *registration) expired(now time.Time) bool {
+	return r.ttl > 0 && now.After(r.expires)
 }
 
 // ErrNilID returned if the Identity has no id specified
@@ -37,6 +89,38 @@ var ErrIDNotFound = errors.New("id is not registered")
 // ErrIDAlreadyRegistered returned if the Identity is already registered in the Discovery Service
 var ErrIDAlreadyRegistered = errors.New("id is already registered")
 
+// lookup returns the registration of the Identity, evicting it if its lease has expired.
+// The caller must hold the lock
+func (d *ds) lookup(id string) (*registration, bool) {
+	r, ok := d.m[id]
+	if ok && r.expired(time.Now()) {
+		d.remove(r)
+		return nil, false
+	}
+	return r, ok
+}
+
+// remove discards the registration, notifying the watchers.
+// The caller must hold the lock
+func (d *ds) remove(r *registration) {
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	delete(d.m, r.id.ID())
+	d.publish(RegistrationRemoved, r, nil)
+}
+
+// evict removes the registration if its lease has expired, without waiting for it to be looked up,
+// so that watchers are notified promptly
+func (d *ds) evict(r *registration) {
+	d.lck.Lock()
+	defer d.lck.Unlock()
+
+	if cur, ok := d.m[r.id.ID()]; ok && cur == r && r.expired(time.Now()) {
+		d.remove(r)
+	}
+}
+
 func (d *ds) Register(id Identity) error {
 	if id == nil {
 		return ErrNilID
@@ -45,11 +129,59 @@ func (d *ds) Register(id Identity) error {
 	d.lck.Lock()
 	defer d.lck.Unlock()
 
-	if _, ok := d.m[id.ID()]; ok {
+	if _, ok := d.lookup(id.ID()); ok {
 		return ErrIDAlreadyRegistered
 	}
 
-	d.m[id.ID()] = id
+	r := &registration{id: id, healthy: true}
+	if a, ok := id.(Annotated); ok {
+		r.meta = a.Metadata().clone()
+	}
+	if l, ok := id.(Leased); ok && l.TTL() > 0 {
+		r.ttl = l.TTL()
+		r.expires = time.Now().Add(r.ttl)
+		r.timer = time.AfterFunc(r.ttl, func() { d.evict(r) })
+	}
+
+	d.m[id.ID()] = r
+	d.publish(RegistrationAdded, r, nil)
+	return nil
+}
+
+func (d *ds) Deregister(id string) error {
+	if len(id) == 0 {
+		return ErrInvalidID
+	}
+
+	d.lck.Lock()
+	defer d.lck.Unlock()
+
+	r, ok := d.lookup(id)
+	if !ok {
+		return ErrIDNotFound
+	}
+
+	d.remove(r)
+	return nil
+}
+
+func (d *ds) Renew(id string) error {
+	if len(id) == 0 {
+		return ErrInvalidID
+	}
+
+	d.lck.Lock()
+	defer d.lck.Unlock()
+
+	r, ok := d.lookup(id)
+	if !ok {
+		return ErrIDNotFound
+	}
+
+	if r.ttl > 0 {
+		r.expires = time.Now().Add(r.ttl)
+		r.timer.Reset(r.ttl)
+	}
 	return nil
 }
 
@@ -61,9 +193,61 @@ func (d *ds) Find(id string) (Location, error) {
 	d.lck.Lock()
 	defer d.lck.Unlock()
 
-	if i, ok := d.m[id]; !ok {
+	if r, ok := d.lookup(id); !ok {
 		return nil, ErrIDNotFound
 	} else {
-		return i.Loc(), nil
+		return r.id.Loc(), nil
+	}
+}
+
+// ErrGroupNotFound returned if no Identities of the group are in the Discovery Service
+var ErrGroupNotFound = errors.New("group has no registered ids")
+
+func (d *ds) FindGroup(group string) ([]string, error) {
+	if len(group) == 0 {
+		return nil, ErrInvalidID
+	}
+
+	d.lck.Lock()
+	defer d.lck.Unlock()
+
+	var ids []string
+	for id := range d.m {
+		r, ok := d.lookup(id)
+		if !ok {
+			continue
+		}
+		if g, ok := r.id.(Grouped); ok && g.Group() == group {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, ErrGroupNotFound
+	}
+
+	slices.Sort(ids)
+	return ids, nil
+}
+
+// renewLease renews the lease of the Identity at a third of its TTL, until the context is Done
+// or the lease cannot be renewed.  Nothing is done if the Identity is not Leased.
+func renewLease(ctx context.Context, ds DiscoveryService, id Identity) {
+	l, ok := id.(Leased)
+	if !ok || l.TTL() <= 0 {
+		return
+	}
+
+	t := time.NewTicker(l.TTL() / 3)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if err := ds.Renew(id.ID()); err != nil {
+				return
+			}
+		}
 	}
 }
@@ -0,0 +1,147 @@
+package startup
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ExitReason describes why a StartableFunction exited, or why shutdown was initiated
+type ExitReason int
+
+const (
+	// ExitReturned indicates the StartableFunction returned of its own accord
+	ExitReturned ExitReason = iota
+	// ExitPanicked indicates the StartableFunction exited due to an unhandled panic
+	ExitPanicked
+	// ExitFailed indicates the ErrStartableFunction returned an error
+	ExitFailed
+	// ExitCancelled indicates the StartableFunction returned after its context was cancelled, or
+	// when describing the trigger, that the context passed to StartNamedFunctions was cancelled
+	// or that Runtime.Shutdown was called
+	ExitCancelled
+	// ExitInterrupted indicates shutdown was initiated by an interrupt, or another shutdown signal
+	ExitInterrupted
+)
+
+func (r ExitReason) String() string {
+	switch r {
+	case ExitReturned:
+		return "returned"
+	case ExitPanicked:
+		return "panicked"
+	case ExitFailed:
+		return "failed"
+	case ExitCancelled:
+		return "cancelled"
+	case ExitInterrupted:
+		return "interrupted"
+	default:
+		return fmt.Sprintf("ExitReason(%d)", int(r))
+	}
+}
+
+// ExitBehaviour determines whether the exit of a StartableFunction triggers shutdown
+type ExitBehaviour int
+
+const (
+	// ShutdownOnExit triggers shutdown whenever the StartableFunction exits (default)
+	ShutdownOnExit ExitBehaviour = iota
+	// ShutdownOnFailure triggers shutdown only if the StartableFunction panics or returns an error
+	ShutdownOnFailure
+	// ShutdownOnPanic triggers shutdown only if the StartableFunction panics
+	ShutdownOnPanic
+	// NeverShutdown does not trigger shutdown, however the StartableFunction exits
+	NeverShutdown
+)
+
+// ErrInvalidExitBehaviour is raised if the ExitBehaviour of a FunctionDeclaration is not recognised
+var ErrInvalidExitBehaviour = errors.New("invalid exit behaviour")
+
+func (b ExitBehaviour) validate() error {
+	if b < ShutdownOnExit || b > NeverShutdown {
+		return ErrInvalidExitBehaviour
+	}
+	return nil
+}
+
+// escalates returns true if exiting with the supplied error should trigger shutdown
+func (b ExitBehaviour) escalates(err error) bool {
+	switch b {
+	case ShutdownOnFailure:
+		return err != nil
+	case ShutdownOnPanic:
+		var pe *PanicError
+		return errors.As(err, &pe)
+	case NeverShutdown:
+		return false
+	default:
+		return true
+	}
+}
+
+// FunctionExit describes the exit of a StartableFunction
+type FunctionExit struct {
+	// Name is the name of the StartableFunction, which is empty if the exit describes
+	// a trigger for shutdown that did not originate from a StartableFunction
+	Name string
+	// Reason describes why the StartableFunction exited
+	Reason ExitReason
+	// Err is the error the StartableFunction exited with, if any.  For a panic, this will
+	// be a *PanicError, which includes the stack trace, otherwise it is the error returned
+	// by an ErrStartableFunction
+	Err error
+}
+
+// ErrShutdownTimeout is reported for each StartableFunction that did not exit within its ShutdownTimeout, or Options.Timeout
+var ErrShutdownTimeout = errors.New("timed out waiting for exit")
+
+// ExitReport describes how the StartableFunctions exited.  It is returned as the error from
+// StartNamedFunctions if any StartableFunction panicked or returned an error, or did not exit within Options.Timeout.
+// Individual failures can be inspected using errors.Is and errors.As, as with errors.Join.
+type ExitReport struct {
+	// Trigger describes the exit that initiated shutdown
+	Trigger FunctionExit
+	// Exits lists the StartableFunctions that exited, in the order they exited
+	Exits []FunctionExit
+	// Abandoned lists the StartableFunctions that did not exit within their ShutdownTimeout, or Options.Timeout
+	Abandoned []string
+	// Stacks holds the goroutine stacks of each abandoned StartableFunction, by name, if requested
+	// using WithAbandonedStacks
+	Stacks map[string]string
+}
+
+// Failed returns true if any StartableFunction exited with an error, or was abandoned
+func (r *ExitReport) Failed() bool {
+	return len(r.Unwrap()) > 0
+}
+
+// Unwrap returns the errors of the StartableFunctions that failed, followed by
+// an error wrapping ErrShutdownTimeout for each StartableFunction that was abandoned
+func (r *ExitReport) Unwrap() []error {
+	var errs []error
+	for _, e := range r.Exits {
+		if e.Err != nil {
+			errs = append(errs, fmt.Errorf("%s %s: %w", e.Name, e.Reason, e.Err))
+		}
+	}
+	for _, name := range r.Abandoned {
+		errs = append(errs, fmt.Errorf("%s: %w", name, ErrShutdownTimeout))
+	}
+	return errs
+}
+
+func (r *ExitReport) Error() string {
+	name := r.Trigger.Name
+	if len(name) == 0 {
+		name = "(external)"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "shutdown triggered by %s %s", name, r.Trigger.Reason)
+	for _, err := range r.Unwrap() {
+		b.WriteString("\n")
+		b.WriteString(err.Error())
+	}
+	return b.String()
+}
@@ -0,0 +1,84 @@
+package startup
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// ErrFunctionExited is raised if FunctionOptions.Go is called once the StartableFunction has exited
+var ErrFunctionExited = errors.New("StartableFunction has exited")
+
+// taskGroup tracks the goroutines started via FunctionOptions.Go during a single run of a StartableFunction
+type taskGroup struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	lck    sync.Mutex
+	closed bool
+	errs   []error
+}
+
+// newTaskGroup creates a taskGroup whose context is cancelled should any of its goroutines fail
+func newTaskGroup(ctx context.Context) *taskGroup {
+	g := &taskGroup{}
+	g.ctx, g.cancel = context.WithCancel(ctx)
+	return g
+}
+
+// Go starts fn in a new goroutine, unless the taskGroup has been closed
+func (g *taskGroup) Go(fn func(context.Context) error) error {
+	g.lck.Lock()
+	defer g.lck.Unlock()
+
+	if g.closed {
+		return ErrFunctionExited
+	}
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+
+		// Returning the error of the context, once the taskGroup has been closed or cancelled, is not a failure
+		if err := callGoroutine(g.ctx, fn); err != nil && !(g.ctx.Err() != nil && isCancellation(err)) {
+			g.lck.Lock()
+			g.errs = append(g.errs, err)
+			g.lck.Unlock()
+
+			g.cancel() // The StartableFunction is asked to exit, as if it had failed itself
+		}
+	}()
+
+	return nil
+}
+
+// wait cancels the context of the taskGroup and waits for its goroutines to exit,
+// returning their errors joined together.  No further goroutines can then be started.
+func (g *taskGroup) wait() error {
+	g.lck.Lock()
+	g.closed = true
+	g.lck.Unlock()
+
+	g.cancel()
+	g.wg.Wait()
+
+	return errors.Join(g.errs...)
+}
+
+// callGoroutine executes fn, converting an unhandled panic into a *PanicError
+func callGoroutine(ctx context.Context, fn func(context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{
+				Func:  runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name(),
+				Value: r,
+				Stack: debug.Stack(),
+			}
+		}
+	}()
+
+	return fn(ctx)
+}
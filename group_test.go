@@ -0,0 +1,139 @@
+package startup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func ExampleFunctionOptions_Go() {
+
+	myFunc := func(ctx context.Context, opts *FunctionOptions, args ...any) error {
+		opts.Go(func(ctx context.Context) error {
+			return errors.New("worker failed")
+		})
+
+		<-ctx.Done() // Cancelled once the worker fails
+		return nil
+	}
+
+	err := StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{Name: "Foo", ErrFunc: myFunc},
+	})
+
+	fmt.Println(err)
+	// Output:
+	// shutdown triggered by Foo failed
+	// Foo failed: worker failed
+}
+
+func TestFunctionOptions_Go_Panic(t *testing.T) {
+
+	myFunc := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		opts.Go(func(ctx context.Context) error {
+			panic("Boom!")
+		})
+		<-ctx.Done()
+	}
+	waiter := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		<-ctx.Done()
+	}
+
+	err := StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{Name: "Waiter", Func: waiter},
+		{Name: "Foo", Func: myFunc},
+	})
+
+	var pe *PanicError
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected *PanicError, got: %v", err)
+	}
+	if report := err.(*ExitReport); report.Trigger.Name != "Foo" || report.Trigger.Reason != ExitPanicked {
+		t.Fatalf("expected trigger to be Foo panicking, got: %s %s", report.Trigger.Name, report.Trigger.Reason)
+	}
+}
+
+func TestFunctionOptions_Go_Wait(t *testing.T) {
+
+	var childExited bool
+
+	myFunc := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		opts.Go(func(ctx context.Context) error {
+			<-ctx.Done()
+			time.Sleep(10 * time.Millisecond)
+			childExited = true
+			return nil
+		})
+	}
+
+	err := StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{Name: "Foo", Func: myFunc},
+	}, WithTimeout(time.Second))
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !childExited {
+		t.Fatal("expected Foo to exit only once its goroutine had exited")
+	}
+}
+
+func TestFunctionOptions_Go_Exited(t *testing.T) {
+
+	optsCh := make(chan *FunctionOptions, 1)
+
+	myFunc := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		optsCh <- opts
+	}
+
+	StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{Name: "Foo", Func: myFunc},
+	})
+
+	opts := <-optsCh
+	if err := opts.Go(func(ctx context.Context) error { return nil }); err != ErrFunctionExited {
+		t.Fatalf("Expected error: ErrFunctionExited, got: %v", err)
+	}
+	if err := (&FunctionOptions{}).Go(func(ctx context.Context) error { return nil }); err != ErrFunctionExited {
+		t.Fatalf("Expected error: ErrFunctionExited, got: %v", err)
+	}
+}
+
+func TestFunctionOptions_Go_Cancelled(t *testing.T) {
+
+	errFailed := errors.New("failed")
+
+	waiting := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	myFunc := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		opts.Go(waiting)
+	}
+
+	err := StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{Name: "Foo", Func: myFunc},
+	}, WithTimeout(time.Second))
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Only the failure is reported, not the cancellation of the other goroutine that it caused
+	failing := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		opts.Go(waiting)
+		opts.Go(func(ctx context.Context) error { return errFailed })
+		<-ctx.Done()
+	}
+
+	err = StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{Name: "Foo", Func: failing},
+	}, WithTimeout(time.Second))
+
+	if !errors.Is(err, errFailed) || errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected error: errFailed alone, got: %v", err)
+	}
+}
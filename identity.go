@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"runtime/pprof"
+	"slices"
+	"sync/atomic"
 	"time"
 )
 
@@ -22,6 +25,11 @@ type ConnectOptions struct {
 	Timeout time.Duration
 	// DisoveryService specifies the DiscoveryService to use when retrieving remote identities
 	DisoveryService DiscoveryService
+	// Balancer chooses the instance to connect to, when connecting to a service (default is round-robin,
+	// shared by the Connects using the same DiscoveryService)
+	Balancer Balancer
+	// Key is passed to the Balancer, such as for consistent hashing
+	Key string
 }
 
 // Identity ties an ID with the means to connect to that ID
@@ -32,18 +40,84 @@ type Identity interface {
 	Loc() Location
 	// Accept allows an Identity to respond to Connection attempts
 	Accept(context.Context) error
-	// Allows an Identity to connect to another Identity specified by the id
+	// Allows an Identity to connect to another Identity specified by the id.  If no Identity has the id,
+	// then it is treated as the name of a service, and one of its instances is chosen by the Balancer
 	Connect(ctx context.Context, id string, opts ...func(*ConnectOptions)) (*Connection, error)
 	// Send allows an Identity to make a request to the remote identity, after Connection is established
 	Send(ctx context.Context, r *Req, ch chan<- *ReqWithChan, opts ...func(*SendOptions)) *Res
 }
 
+// IdentityOptions allow further configuration when creating an Identity
+type IdentityOptions struct {
+	// Metrics records the connections made and requests sent and handled by the Identity
+	Metrics Metrics
+	// Group, if set, is the group the Identity is registered under with the DiscoveryService
+	Group string
+	// TTL, if set, is the duration of the lease of the registration of the Identity with the DiscoveryService
+	TTL time.Duration
+	// Metadata describes the Identity to the DiscoveryService
+	Metadata Metadata
+}
+
+// WithIdentityMetrics specifies where the metrics of the Identity are recorded
+func WithIdentityMetrics(m Metrics) func(*IdentityOptions) {
+	return func(o *IdentityOptions) {
+		if m != nil {
+			o.Metrics = m
+		}
+	}
+}
+
+// WithIdentityGroup specifies the group the Identity is registered under with the DiscoveryService,
+// so that members of the group, such as the replicas of a FunctionDeclaration, can be found together.
+// The group is the name of the service that the Identity is an instance of, which can be given to Connect.
+func WithIdentityGroup(group string) func(*IdentityOptions) {
+	return func(o *IdentityOptions) {
+		o.Group = group
+	}
+}
+
+// WithIdentityTTL specifies that the registration of the Identity with the DiscoveryService is leased
+// for the duration d, after which it expires unless renewed using DiscoveryService.Renew.
+func WithIdentityTTL(d time.Duration) func(*IdentityOptions) {
+	return func(o *IdentityOptions) {
+		if d > 0 {
+			o.TTL = d
+		}
+	}
+}
+
+// WithIdentityMetadata specifies the Metadata describing the Identity to the DiscoveryService,
+// so that it can be found using DiscoveryService.Query
+func WithIdentityMetadata(m Metadata) func(*IdentityOptions) {
+	return func(o *IdentityOptions) {
+		o.Metadata = m.clone()
+	}
+}
+
+// Loaded is implemented by Identities that report their load, for use by a Balancer
+type Loaded interface {
+	// Outstanding returns the number of requests being handled
+	Outstanding() int
+}
+
+// Grouped is implemented by Identities that are members of a group
+type Grouped interface {
+	// Group returns the name of the group, or "" if the Identity is not a member of a group
+	Group() string
+}
+
 // CreateAndRegisterID creates an Identity and attempts to register it on the DiscoveryService
-func CreateAndRegisterID(ds DiscoveryService, id string, d time.Duration, h Handler) (Identity, error) {
+func CreateAndRegisterID(ds DiscoveryService, id string, d time.Duration, h Handler, opts ...func(*IdentityOptions)) (Identity, error) {
 	if ds == nil {
 		return nil, ErrNoDiscoveryService
 	}
 
+	o := IdentityOptions{Metrics: noopMetrics{}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	// Identities are only connectable if they have a Handler
 	var ch chan *Connect
 	if h != nil {
@@ -55,6 +129,10 @@ func CreateAndRegisterID(ds DiscoveryService, id string, d time.Duration, h Hand
 		ch:          ch,
 		h:           h,
 		idleTimeout: d,
+		m:           o.Metrics,
+		group:       o.Group,
+		ttl:         o.TTL,
+		meta:        o.Metadata,
 	}
 	if err := ds.Register(i); err != nil {
 		return nil, fmt.Errorf("%s already exists!: %v", id, err)
@@ -71,12 +149,33 @@ type identity struct {
 	ch          chan *Connect
 	h           Handler
 	idleTimeout time.Duration
+	m           Metrics
+	group       string
+	ttl         time.Duration
+	meta        Metadata
+	outstanding atomic.Int64
 }
 
 func (i *identity) ID() string {
 	return i.id
 }
 
+func (i *identity) Group() string {
+	return i.group
+}
+
+func (i *identity) Outstanding() int {
+	return int(i.outstanding.Load())
+}
+
+func (i *identity) Metadata() Metadata {
+	return i.meta.clone()
+}
+
+func (i *identity) TTL() time.Duration {
+	return i.ttl
+}
+
 func (i *identity) Loc() Location {
 	return i.ch
 }
@@ -85,23 +184,42 @@ func (i *identity) Loc() Location {
 var ErrNoHandlerCannotAccept = errors.New("no handler has been specified, so cannot accept connections")
 
 func (i *identity) Accept(ctx context.Context) error {
+	return i.accept(ctx, nil)
+}
+
+// accept implements Accept, calling listening (if provided) once Connection requests can be received.
+// Accepting and handling are labelled with the ID of the Identity, for profiling.
+func (i *identity) accept(ctx context.Context, listening func()) error {
 	if i.h == nil {
 		return ErrNoHandlerCannotAccept
 	}
 
+	pprof.Do(ctx, pprof.Labels(PprofLabelIdentity, i.id), func(ctx context.Context) {
+		if listening != nil {
+			listening()
+		}
+
+		i.acceptConnections(ctx)
+	})
+	return nil
+}
+
+// acceptConnections responds to Connection requests until the context is Done
+func (i *identity) acceptConnections(ctx context.Context) {
 	for {
 		select {
 		case <-ctx.Done():
-			return nil
+			return
 		case c, ok := <-i.ch:
 			if !ok {
-				return nil
+				return
 			}
 			// For now, ignore ID
 			ch := reqChPool.Get().(chan *ReqWithChan)
 			go i.handle(ctx, ch)
 
 			c.Chan <- &Connection{
+				ID:      i.id,
 				ReqChan: ch,
 				Timeout: i.idleTimeout,
 			}
@@ -112,7 +230,7 @@ func (i *identity) Accept(ctx context.Context) error {
 func (i *identity) handle(ctx context.Context, ch chan *ReqWithChan) {
 	defer reqChPool.Put(ch)
 
-	hWrapper := func(req *Req) (res *Res) {
+	hWrapper := func(ctx context.Context, req *Req) (res *Res) {
 		res = &Res{}
 		defer func() {
 			if r := recover(); r != nil {
@@ -135,7 +253,16 @@ func (i *identity) handle(ctx context.Context, ch chan *ReqWithChan) {
 			if !ok {
 				return
 			}
-			r.Chan <- hWrapper(&Req{Type: r.Type, Data: r.Data})
+			var res *Res
+			i.outstanding.Add(1)
+			pprof.Do(ctx, pprof.Labels(PprofLabelReqType, r.Type), func(ctx context.Context) {
+				res = hWrapper(ctx, &Req{Type: r.Type, Data: r.Data})
+			})
+			i.outstanding.Add(-1)
+			if res.Status == Error {
+				i.m.IncCounter(MetricHandlerErrors, Label{"id", i.id})
+			}
+			r.Chan <- res
 		case <-time.After(i.idleTimeout):
 			return
 		}
@@ -177,6 +304,23 @@ func WithConnectDiscoveryService(ds DiscoveryService) func(*ConnectOptions) {
 	}
 }
 
+// WithConnectBalancer overrides the default Balancer, which chooses between the instances of a service
+func WithConnectBalancer(b Balancer) func(*ConnectOptions) {
+	return func(co *ConnectOptions) {
+		if b != nil {
+			co.Balancer = b
+		}
+	}
+}
+
+// WithConnectKey specifies the key passed to the Balancer, so that connections for the same key
+// are made to the same instance of a service when using NewConsistentHashBalancer
+func WithConnectKey(key string) func(*ConnectOptions) {
+	return func(co *ConnectOptions) {
+		co.Key = key
+	}
+}
+
 // ErrNoDiscoveryService returned when a DiscoveryService is not specified (there is no default service)
 var ErrNoDiscoveryService = errors.New("cannot connect, no Discovery Service available")
 
@@ -184,6 +328,20 @@ var ErrNoDiscoveryService = errors.New("cannot connect, no Discovery Service ava
 var ErrCannotConnect = errors.New("identity exists but cannot be contacted")
 
 func (i *identity) Connect(ctx context.Context, id string, opts ...func(*ConnectOptions)) (*Connection, error) {
+	begin := time.Now()
+
+	c, err := i.connect(ctx, id, opts...)
+
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	i.m.ObserveDuration(MetricConnectDuration, time.Since(begin), Label{"id", id}, Label{"result", result})
+
+	return c, err
+}
+
+func (i *identity) connect(ctx context.Context, id string, opts ...func(*ConnectOptions)) (*Connection, error) {
 
 	var o ConnectOptions = defaultConnectOptions
 	for _, opt := range opts {
@@ -195,26 +353,50 @@ func (i *identity) Connect(ctx context.Context, id string, opts ...func(*Connect
 
 	loc, err := o.DisoveryService.Find(id)
 	if err != nil {
-		return nil, ErrIDNotFound
+		// Otherwise the id may name a service, with healthy instances accepting Connection requests to choose between
+		instances := slices.DeleteFunc(o.DisoveryService.Query(Query{Group: id, HealthyOnly: true}),
+			func(r Registration) bool { return !r.Connectable })
+		if len(instances) == 0 {
+			return nil, ErrIDNotFound
+		}
+		b := o.Balancer
+		if b == nil {
+			b = defaultBalancer(o.DisoveryService)
+		}
+		if loc, err = o.DisoveryService.Find(b.Pick(id, o.Key, instances)); err != nil {
+			return nil, ErrIDNotFound
+		}
 	}
 	if loc == nil {
 		return nil, ErrCannotConnect
 	}
 
+	// The Timeout applies to both the request and the response, as the remote Identity
+	// may have stopped accepting Connection requests
+	timeout := time.NewTimer(o.Timeout)
+	defer timeout.Stop()
+
 	ch := connChPool.Get().(chan *Connection)
-	defer connChPool.Put(ch)
 
-	loc <- &Connect{
-		ReqID: i.id,
-		Chan:  ch,
+	select {
+	case <-ctx.Done():
+		connChPool.Put(ch)
+		return nil, ErrContextCompleted
+	case <-timeout.C:
+		connChPool.Put(ch)
+		return nil, ErrConnectTimeout
+	case loc <- &Connect{ReqID: i.id, Chan: ch}:
 	}
 
+	// Once the request is accepted, the chan is only reused after the response is received,
+	// as otherwise a late response would be seen by a subsequent Connect
 	select {
 	case <-ctx.Done():
 		return nil, ErrContextCompleted
-	case <-time.After(o.Timeout):
+	case <-timeout.C:
 		return nil, ErrConnectTimeout
 	case c, ok := <-ch:
+		connChPool.Put(ch)
 		if !ok {
 			return nil, ErrConnectionChan
 		}
@@ -236,7 +418,24 @@ func WithSendTimeout(d time.Duration) func(*SendOptions) {
 	}
 }
 
-func (i *identity) Send(ctx context.Context, req *Req, ch chan<- *ReqWithChan, opts ...func(*SendOptions)) (r *Res) {
+func (i *identity) Send(ctx context.Context, req *Req, ch chan<- *ReqWithChan, opts ...func(*SendOptions)) *Res {
+	begin := time.Now()
+
+	r := i.send(ctx, req, ch, opts...)
+
+	status := "cancelled"
+	if r != nil {
+		status = statusLabel(r.Status)
+		if r.Status == RequestTimeout {
+			i.m.IncCounter(MetricRequestTimeouts, Label{"id", i.id})
+		}
+	}
+	i.m.ObserveDuration(MetricSendDuration, time.Since(begin), Label{"id", i.id}, Label{"status", status})
+
+	return r
+}
+
+func (i *identity) send(ctx context.Context, req *Req, ch chan<- *ReqWithChan, opts ...func(*SendOptions)) (r *Res) {
 
 	var o SendOptions = defaultSendOptions
 	for _, opt := range opts {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"testing"
 	"time"
 )
 
@@ -64,3 +65,228 @@ func ExampleCreateAndRegisterID() {
 
 	// Output: true
 }
+
+func TestDiscoveryService_Deregister(t *testing.T) {
+
+	ds := NewDiscoveryService()
+
+	if _, err := CreateAndRegisterID(ds, "Bob", time.Minute, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := ds.Deregister("Bob"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := ds.Find("Bob"); err != ErrIDNotFound {
+		t.Fatalf("Expected error: ErrIDNotFound, got: %v", err)
+	}
+	if err := ds.Deregister("Bob"); err != ErrIDNotFound {
+		t.Fatalf("Expected error: ErrIDNotFound, got: %v", err)
+	}
+	if err := ds.Deregister(""); err != ErrInvalidID {
+		t.Fatalf("Expected error: ErrInvalidID, got: %v", err)
+	}
+
+	// Once deregistered, the id can be registered again
+	if _, err := CreateAndRegisterID(ds, "Bob", time.Minute, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestConnect_NotAccepting(t *testing.T) {
+
+	ds := NewDiscoveryService()
+
+	// Bob could accept connections, but is not doing so
+	handler := func(ctx context.Context, req *Req, res *Res) {}
+	if _, err := CreateAndRegisterID(ds, "Bob", time.Minute, handler); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	alice, err := CreateAndRegisterID(ds, "Alice", time.Minute, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = alice.Connect(context.Background(), "Bob", WithConnectDiscoveryService(ds), WithConnectTimeout(10*time.Millisecond))
+	if err != ErrConnectTimeout {
+		t.Fatalf("Expected error: ErrConnectTimeout, got: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = alice.Connect(ctx, "Bob", WithConnectDiscoveryService(ds))
+	if err != ErrContextCompleted {
+		t.Fatalf("Expected error: ErrContextCompleted, got: %v", err)
+	}
+}
+
+// exitNotifier reports the names of the StartableFunctions as they exit
+type exitNotifier struct {
+	NoopObserver
+	ch chan string
+}
+
+func (n *exitNotifier) OnExit(e ExitEvent) {
+	n.ch <- e.Exit.Name
+}
+
+func TestDeregisterOnExit(t *testing.T) {
+
+	handler := func(ctx context.Context, req *Req, res *Res) {}
+
+	var ids []Identity
+	bob := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		ids = append(ids, opts.Identity)
+	}
+	other := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		<-ctx.Done()
+	}
+
+	n := &exitNotifier{ch: make(chan string, 2)}
+
+	r, err := Start(context.Background(), []FunctionDeclaration{
+		{
+			Name:          "Bob",
+			Func:          bob,
+			Handler:       handler,
+			ExitBehaviour: ShutdownOnFailure,
+			RestartPolicy: RestartPolicy{Mode: RestartAlways, MaxRestarts: 1, InitialBackoff: time.Millisecond},
+		},
+		{Name: "Other", Func: other},
+	}, WithObserver(n))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer r.Shutdown(context.Background())
+
+	if name := <-n.ch; name != "Bob" {
+		t.Fatalf("expected Bob to exit, got: %s", name)
+	}
+
+	if len(ids) != 2 || ids[0] == nil || ids[0] == ids[1] {
+		t.Fatalf("expected Bob to be registered afresh when restarted, got: %v", ids)
+	}
+	if _, err := r.f.funcOps.DiscoveryService.Find("Bob"); err != ErrIDNotFound {
+		t.Fatalf("Expected error: ErrIDNotFound, got: %v", err)
+	}
+}
+
+func TestDiscoveryService_Lease(t *testing.T) {
+
+	ds := NewDiscoveryService()
+
+	bob, err := CreateAndRegisterID(ds, "Bob", time.Minute, nil, WithIdentityTTL(20*time.Millisecond))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Renewing extends the lease beyond its TTL
+	for range 4 {
+		time.Sleep(10 * time.Millisecond)
+		if err := ds.Renew(bob.ID()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if _, err := ds.Find("Bob"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+
+	if _, err := ds.Find("Bob"); err != ErrIDNotFound {
+		t.Fatalf("Expected error: ErrIDNotFound, got: %v", err)
+	}
+	if err := ds.Renew("Bob"); err != ErrIDNotFound {
+		t.Fatalf("Expected error: ErrIDNotFound, got: %v", err)
+	}
+
+	// Once expired, the id can be registered again
+	if _, err := CreateAndRegisterID(ds, "Bob", time.Minute, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestWithRegistrationTTL(t *testing.T) {
+
+	found := make(chan error, 2)
+
+	handler := func(ctx context.Context, req *Req, res *Res) {}
+	fn := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		opts.Ready()
+		<-ctx.Done()
+	}
+	check := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		time.Sleep(60 * time.Millisecond)
+		for _, id := range []string{"Bob", "Alice"} {
+			_, err := opts.DiscoveryService.Find(id)
+			found <- err
+		}
+	}
+
+	StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{Name: "Bob", Func: fn, Handler: handler},
+		{Name: "Alice", Func: fn, RegisterWithDiscoveryService: true},
+		{Name: "Check", Func: check, DependsOn: []string{"Bob", "Alice"}},
+	}, WithRegistrationTTL(20*time.Millisecond))
+
+	for range 2 {
+		if err := <-found; err != nil {
+			t.Fatalf("expected leases to be renewed automatically, got: %v", err)
+		}
+	}
+
+	err := StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{Func: fn},
+	}, WithRegistrationTTL(0))
+	if err != ErrInvalidTimeout {
+		t.Fatalf("Expected error: ErrInvalidTimeout, got: %v", err)
+	}
+}
+
+func TestFunctionOptions_Heartbeat(t *testing.T) {
+
+	stuck := make(chan struct{})
+	found := make(chan error, 2)
+
+	// Bob renews his lease whilst he makes progress, but then becomes stuck
+	handler := func(ctx context.Context, req *Req, res *Res) {}
+	bob := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		opts.Ready()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-stuck:
+				<-ctx.Done()
+				return
+			case <-time.After(5 * time.Millisecond):
+				if err := opts.Heartbeat(); err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}
+		}
+	}
+	check := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		time.Sleep(60 * time.Millisecond)
+		_, err := opts.DiscoveryService.Find("Bob")
+		found <- err
+
+		// Although Bob is still accepting Connection requests, his lease expires once he is stuck
+		close(stuck)
+		time.Sleep(60 * time.Millisecond)
+		_, err = opts.DiscoveryService.Find("Bob")
+		found <- err
+	}
+
+	StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{Name: "Bob", Func: bob, Handler: handler},
+		{Name: "Check", Func: check, DependsOn: []string{"Bob"}},
+	}, WithRegistrationTTL(20*time.Millisecond), WithHeartbeatRenewal())
+
+	if err := <-found; err != nil {
+		t.Fatalf("expected lease to be renewed by Heartbeat, got: %v", err)
+	}
+	if err := <-found; err != ErrIDNotFound {
+		t.Fatalf("Expected error: ErrIDNotFound, got: %v", err)
+	}
+}
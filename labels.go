@@ -0,0 +1,26 @@
+package startup
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// The pprof labels applied to goroutines, allowing profiles and goroutine dumps to be filtered
+const (
+	// PprofLabelFunction is applied to the goroutines of each StartableFunction, including any goroutines
+	// they start, with the name of the StartableFunction as its value
+	PprofLabelFunction = "startup_function"
+	// PprofLabelIdentity is applied to the goroutines accepting and handling Connections for an Identity,
+	// with the ID of the Identity as its value
+	PprofLabelIdentity = "startup_identity"
+	// PprofLabelReqType is applied while a Handler processes a Req, with the Type of the Req as its value
+	PprofLabelReqType = "startup_req_type"
+)
+
+// withFunctionLabels applies the pprof labels of the StartableFunction to the calling goroutine,
+// returning the labelled context so that pprof.Do can be used to add further labels
+func withFunctionLabels(ctx context.Context, name string) context.Context {
+	ctx = pprof.WithLabels(ctx, pprof.Labels(PprofLabelFunction, name))
+	pprof.SetGoroutineLabels(ctx)
+	return ctx
+}
@@ -0,0 +1,71 @@
+package startup
+
+import (
+	"context"
+	"fmt"
+	"runtime/pprof"
+	"testing"
+	"time"
+)
+
+// labels returns the pprof labels of the context, formatted for comparison
+func labels(ctx context.Context) string {
+	var s string
+	for _, key := range []string{PprofLabelFunction, PprofLabelIdentity, PprofLabelReqType} {
+		v, _ := pprof.Label(ctx, key)
+		s += fmt.Sprintf("%s=%s ", key, v)
+	}
+	return s
+}
+
+func TestPprofLabels_Handler(t *testing.T) {
+
+	got := make(chan string, 1)
+
+	bobHandler := func(ctx context.Context, req *Req, res *Res) {
+		got <- labels(ctx)
+		res.Status = Success
+	}
+	bob := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		<-ctx.Done()
+	}
+	alice := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		c, err := opts.Identity.Connect(ctx, "Bob", WithConnectDiscoveryService(opts.DiscoveryService))
+		if err != nil {
+			panic(err)
+		}
+		opts.Identity.Send(ctx, &Req{Type: "text"}, c.ReqChan)
+	}
+
+	StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{Name: "Bob", Func: bob, Handler: bobHandler},
+		{Name: "Alice", Func: alice, RegisterWithDiscoveryService: true},
+	}, WithTimeout(time.Second))
+
+	expected := "startup_function=Bob startup_identity=Bob startup_req_type=text "
+	if s := <-got; s != expected {
+		t.Fatalf("expected labels %q, got: %q", expected, s)
+	}
+}
+
+func TestPprofLabels_Supervisor(t *testing.T) {
+
+	got := make(chan string, 1)
+
+	child := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		got <- labels(ctx)
+	}
+
+	s := &Supervisor{
+		Children: []FunctionDeclaration{{Name: "Child", Func: child}},
+	}
+
+	StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{Name: "Supervisor", ErrFunc: s.Run},
+	})
+
+	expected := "startup_function=Child startup_identity= startup_req_type= "
+	if s := <-got; s != expected {
+		t.Fatalf("expected labels %q, got: %q", expected, s)
+	}
+}
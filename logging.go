@@ -0,0 +1,84 @@
+package startup
+
+import (
+	"context"
+	"errors"
+	"log"
+	"log/slog"
+)
+
+// Attribute keys used in the structured log records
+const (
+	LogKeyFunction = "function" // The name of the StartableFunction
+	LogKeyID       = "id"       // The ID of the Identity of the StartableFunction
+	LogKeyEvent    = "event"    // The lifecycle event being recorded
+	LogKeyDuration = "duration" // The duration associated with the event
+	LogKeyPanic    = "panic"    // The value passed to panic()
+	LogKeyStack    = "stack"    // The stack trace of a panic
+	LogKeyError    = "error"    // The error associated with the event
+)
+
+// WithSlogLogger allows a slog.Logger to be specified for capturing StartFunctions activity as
+// structured records, with attributes identifying the StartableFunction and the event.
+// This takes precedence over WithLogging.
+func WithSlogLogger(l *slog.Logger) OptionSetter {
+	return func(o *Options) error {
+		o.SlogLogger = l
+		return nil
+	}
+}
+
+// legacyHandler adapts a log.Logger to a slog.Handler, writing only the message of each record,
+// so that the output of loggers specified with WithLogging is unchanged
+type legacyHandler struct {
+	l          *log.Logger
+	panicsOnly bool
+}
+
+func (h legacyHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return !h.panicsOnly || level >= slog.LevelError
+}
+
+func (h legacyHandler) Handle(_ context.Context, r slog.Record) error {
+	h.l.Println(r.Message)
+	return nil
+}
+
+func (h legacyHandler) WithAttrs([]slog.Attr) slog.Handler {
+	return h
+}
+
+func (h legacyHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+// newLogger returns the slog.Logger to be used, based on the Options, which discards
+// all records if no logging has been requested
+func (o *Options) newLogger() *slog.Logger {
+	switch {
+	case o.SlogLogger != nil:
+		return o.SlogLogger
+	case o.Logger != nil:
+		return slog.New(legacyHandler{l: o.Logger, panicsOnly: o.ReportPanicsOnly})
+	default:
+		return slog.New(slog.DiscardHandler)
+	}
+}
+
+// logger records activity, with attributes provided as alternating keys and values, or as slog.Attrs
+func (f *funcMgr) logger(msg string, event string, args ...any) {
+	f.log.Info(msg, append([]any{LogKeyEvent, event}, args...)...)
+}
+
+// logPanic records a failure, always using the message of the error for compatibility with
+// unstructured loggers.  The value and stack of a panic are included as attributes.
+func (f *funcMgr) logPanic(err error, event string, args ...any) {
+	args = append([]any{LogKeyEvent, event, LogKeyError, err}, args...)
+
+	var pe *PanicError
+	if errors.As(err, &pe) {
+		args = append(args, LogKeyPanic, pe.Value, LogKeyStack, string(pe.Stack))
+	}
+
+	f.log.Error(err.Error(), args...)
+}
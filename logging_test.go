@@ -0,0 +1,81 @@
+package startup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// decodeRecords returns the JSON log records written to buf
+func decodeRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		r := map[string]any{}
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+func TestWithSlogLogger(t *testing.T) {
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	myFunc := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		opts.Logger.Info("working")
+		panic("Boom!")
+	}
+
+	StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{Name: "Foo", Func: myFunc},
+	}, WithSlogLogger(logger))
+
+	var working, panicked, exited bool
+	for _, r := range decodeRecords(t, &buf) {
+		switch {
+		case r["msg"] == "working":
+			working = r[LogKeyFunction] == "Foo"
+		case r[LogKeyEvent] == "panicked":
+			panicked = r[LogKeyFunction] == "Foo" && r[LogKeyPanic] == "Boom!" && r[LogKeyStack] != "" && r["level"] == "ERROR"
+		case r[LogKeyEvent] == "exited":
+			_, ok := r[LogKeyDuration]
+			exited = r[LogKeyFunction] == "Foo" && ok
+		}
+	}
+
+	if !working {
+		t.Fatal("expected record from FunctionOptions.Logger with function attribute")
+	}
+	if !panicked {
+		t.Fatal("expected panicked record with function, panic and stack attributes")
+	}
+	if !exited {
+		t.Fatal("expected exited record with function and duration attributes")
+	}
+}
+
+func TestFunctionOptions_Logger_Discard(t *testing.T) {
+
+	myFunc := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		if opts.Logger == nil {
+			panic("expected Logger to be available without logging")
+		}
+		opts.Logger.Info("discarded")
+	}
+
+	err := StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{Func: myFunc},
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
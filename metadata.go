@@ -0,0 +1,117 @@
+package startup
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Metadata describes an Identity, so that it can be discovered by what it does rather than by its ID
+type Metadata struct {
+	// Version is the version of the Identity
+	Version string
+	// Tags are arbitrary labels, such as "primary" or "eu-west"
+	Tags []string
+	// Capabilities are the features the Identity provides
+	Capabilities []string
+	// ReqTypes are the Req.Types handled by the Identity
+	ReqTypes []string
+}
+
+// clone returns a copy of the Metadata that shares no slices with the original
+func (m Metadata) clone() Metadata {
+	m.Tags = slices.Clone(m.Tags)
+	m.Capabilities = slices.Clone(m.Capabilities)
+	m.ReqTypes = slices.Clone(m.ReqTypes)
+	return m
+}
+
+// Annotated is implemented by Identities that carry Metadata
+type Annotated interface {
+	// Metadata returns the Metadata of the Identity when it is registered
+	Metadata() Metadata
+}
+
+// Query selects registrations with the DiscoveryService.  Unset fields match all registrations, and all
+// of the Tags and Capabilities must be present.  Query.Matches can also be used as a filter for Watch, which
+// then reports registrations as added or removed as they start or stop matching.
+type Query struct {
+	// Group, if set, is the group that the Identity must be a member of
+	Group string
+	// Version, if set, is the Version the Identity must have
+	Version string
+	// Tags are the Tags the Identity must have
+	Tags []string
+	// Capabilities are the Capabilities the Identity must have
+	Capabilities []string
+	// ReqType, if set, is a Req.Type the Identity must handle
+	ReqType string
+	// HealthyOnly, if true, excludes Identities that are not healthy
+	HealthyOnly bool
+}
+
+// containsAll returns true if all of the values are in s
+func containsAll(s, values []string) bool {
+	for _, v := range values {
+		if !slices.Contains(s, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// Matches returns true if the Registration is selected by the Query
+func (q Query) Matches(r Registration) bool {
+	switch {
+	case len(q.Group) > 0 && r.Group != q.Group:
+		return false
+	case len(q.Version) > 0 && r.Metadata.Version != q.Version:
+		return false
+	case len(q.ReqType) > 0 && !slices.Contains(r.Metadata.ReqTypes, q.ReqType):
+		return false
+	case q.HealthyOnly && !r.Healthy:
+		return false
+	}
+	return containsAll(r.Metadata.Tags, q.Tags) && containsAll(r.Metadata.Capabilities, q.Capabilities)
+}
+
+func (d *ds) List() []Registration {
+	return d.Query(Query{})
+}
+
+func (d *ds) Query(q Query) []Registration {
+	d.lck.Lock()
+	defer d.lck.Unlock()
+
+	var regs []Registration
+	for id := range d.m {
+		r, ok := d.lookup(id)
+		if !ok {
+			continue
+		}
+		if reg := r.snapshot(); q.Matches(reg) {
+			regs = append(regs, reg)
+		}
+	}
+
+	slices.SortFunc(regs, func(a, b Registration) int { return cmp.Compare(a.ID, b.ID) })
+	return regs
+}
+
+func (d *ds) SetMetadata(id string, m Metadata) error {
+	if len(id) == 0 {
+		return ErrInvalidID
+	}
+
+	d.lck.Lock()
+	defer d.lck.Unlock()
+
+	r, ok := d.lookup(id)
+	if !ok {
+		return ErrIDNotFound
+	}
+
+	prev := r.snapshot()
+	r.meta = m.clone()
+	d.publish(RegistrationUpdated, r, &prev)
+	return nil
+}
@@ -0,0 +1,174 @@
+package startup
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+func ExampleQuery() {
+
+	handler := func(ctx context.Context, req *Req, res *Res) {
+		res.Status = Success
+	}
+	worker := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		<-ctx.Done()
+	}
+	router := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		// Find any identity that handles "resize", rather than relying on its name
+		for _, r := range opts.DiscoveryService.Query(Query{ReqType: "resize", HealthyOnly: true}) {
+			fmt.Println(r.ID, r.Metadata.Version)
+		}
+	}
+
+	StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{Name: "Thumbnailer", Func: worker, Handler: handler, Metadata: Metadata{Version: "1.2.0", ReqTypes: []string{"resize"}}},
+		{Name: "Transcoder", Func: worker, Handler: handler, Metadata: Metadata{Version: "0.9.1", ReqTypes: []string{"transcode"}}},
+		{Name: "Router", Func: router},
+	})
+	// Output:
+	// Thumbnailer 1.2.0
+}
+
+// ids returns the IDs of the Registrations
+func ids(regs []Registration) []string {
+	var s []string
+	for _, r := range regs {
+		s = append(s, r.ID)
+	}
+	return s
+}
+
+func TestDiscoveryService_Query(t *testing.T) {
+
+	ds := NewDiscoveryService()
+
+	register := func(id string, m Metadata, opts ...func(*IdentityOptions)) {
+		if _, err := CreateAndRegisterID(ds, id, time.Minute, nil, append(opts, WithIdentityMetadata(m))...); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	register("C", Metadata{Version: "2", Tags: []string{"eu", "primary"}, Capabilities: []string{"gpu"}})
+	register("A", Metadata{Version: "1", Tags: []string{"eu"}, ReqTypes: []string{"text"}}, WithIdentityGroup("G"))
+	register("B", Metadata{Version: "1", Tags: []string{"us"}, Capabilities: []string{"gpu", "ssd"}}, WithIdentityGroup("G"))
+
+	if got := ids(ds.List()); !slices.Equal(got, []string{"A", "B", "C"}) {
+		t.Fatalf("expected all registrations in order, got: %v", got)
+	}
+
+	tests := []struct {
+		q        Query
+		expected []string
+	}{
+		{Query{Tags: []string{"eu"}}, []string{"A", "C"}},
+		{Query{Tags: []string{"eu", "primary"}}, []string{"C"}},
+		{Query{Capabilities: []string{"gpu"}}, []string{"B", "C"}},
+		{Query{Capabilities: []string{"gpu"}, Group: "G"}, []string{"B"}},
+		{Query{Version: "1"}, []string{"A", "B"}},
+		{Query{ReqType: "text"}, []string{"A"}},
+		{Query{Tags: []string{"asia"}}, nil},
+	}
+	for _, test := range tests {
+		if got := ids(ds.Query(test.q)); !slices.Equal(got, test.expected) {
+			t.Fatalf("expected %v for %+v, got: %v", test.expected, test.q, got)
+		}
+	}
+
+	ds.SetHealth("A", false)
+	if got := ids(ds.Query(Query{Version: "1", HealthyOnly: true})); !slices.Equal(got, []string{"B"}) {
+		t.Fatalf("expected unhealthy registrations to be excluded, got: %v", got)
+	}
+}
+
+func TestDiscoveryService_SetMetadata(t *testing.T) {
+
+	ds := NewDiscoveryService()
+
+	tags := []string{"eu"}
+	if _, err := CreateAndRegisterID(ds, "Bob", time.Minute, nil, WithIdentityMetadata(Metadata{Tags: tags})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tags[0] = "us" // Metadata is copied, so this has no effect
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, _ := ds.Watch(ctx, Query{Capabilities: []string{"gpu"}}.Matches)
+
+	if err := ds.SetMetadata("Bob", Metadata{Tags: []string{"eu"}, Capabilities: []string{"gpu"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e := nextEvent(t, events); e.Type != RegistrationAdded || !slices.Equal(e.Registration.Metadata.Capabilities, []string{"gpu"}) {
+		t.Fatalf("expected Bob to be added as he now matches, got: %v", e)
+	}
+	if got := ids(ds.Query(Query{Tags: []string{"eu"}, Capabilities: []string{"gpu"}})); !slices.Equal(got, []string{"Bob"}) {
+		t.Fatalf("expected Bob to match, got: %v", got)
+	}
+
+	if err := ds.SetMetadata("Bob", Metadata{Tags: []string{"eu"}, Capabilities: []string{"gpu", "ssd"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e := nextEvent(t, events); e.Type != RegistrationUpdated || e.Registration.ID != "Bob" {
+		t.Fatalf("expected Bob to be updated, got: %v", e)
+	}
+
+	// Once Bob no longer matches, he is reported as removed
+	if err := ds.SetMetadata("Bob", Metadata{Tags: []string{"eu"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e := nextEvent(t, events); e.Type != RegistrationRemoved || e.Registration.ID != "Bob" {
+		t.Fatalf("expected Bob to be removed, got: %v", e)
+	}
+
+	if err := ds.SetMetadata("Alice", Metadata{}); err != ErrIDNotFound {
+		t.Fatalf("Expected error: ErrIDNotFound, got: %v", err)
+	}
+}
+
+func TestDiscoveryService_WatchQuery(t *testing.T) {
+
+	ds := NewDiscoveryService()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, _ := ds.Watch(ctx, Query{HealthyOnly: true}.Matches)
+
+	CreateAndRegisterID(ds, "Bob", time.Minute, nil)
+	if e := nextEvent(t, events); e.Type != RegistrationAdded || e.Registration.ID != "Bob" {
+		t.Fatalf("expected Bob to be added, got: %v", e)
+	}
+
+	// Changes in health move Bob out of, and back into, the view
+	ds.SetHealth("Bob", false)
+	if e := nextEvent(t, events); e.Type != RegistrationRemoved || e.Registration.ID != "Bob" {
+		t.Fatalf("expected Bob to be removed, got: %v", e)
+	}
+
+	// Alice is not reported as removed when deregistered, as she has already left the view
+	ds.SetHealth("Bob", true)
+	ds.Deregister("Bob")
+	CreateAndRegisterID(ds, "Alice", time.Minute, nil)
+	ds.SetHealth("Alice", false)
+	ds.Deregister("Alice")
+
+	for _, want := range []RegistrationEvent{
+		{Type: RegistrationAdded, Registration: Registration{ID: "Bob"}},
+		{Type: RegistrationRemoved, Registration: Registration{ID: "Bob"}},
+		{Type: RegistrationAdded, Registration: Registration{ID: "Alice"}},
+		{Type: RegistrationRemoved, Registration: Registration{ID: "Alice"}},
+	} {
+		if e := nextEvent(t, events); e.Type != want.Type || e.Registration.ID != want.Registration.ID {
+			t.Fatalf("expected %s %s, got: %v", want.Registration.ID, want.Type, e)
+		}
+	}
+
+	select {
+	case e := <-events:
+		t.Fatalf("unexpected event: %v", e)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
@@ -0,0 +1,280 @@
+package startup
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Names of the metrics recorded by the runtime and by Identities
+const (
+	MetricFunctionStarts   = "startup_function_starts_total"       // Counter, labelled by function
+	MetricFunctionExits    = "startup_function_exits_total"        // Counter, labelled by function and reason
+	MetricFunctionPanics   = "startup_function_panics_total"       // Counter, labelled by function
+	MetricFunctionRestarts = "startup_function_restarts_total"     // Counter, labelled by function
+	MetricShutdownDuration = "startup_shutdown_duration_seconds"   // Histogram
+	MetricConnectDuration  = "startup_connect_duration_seconds"    // Histogram, labelled by id and result
+	MetricSendDuration     = "startup_send_duration_seconds"       // Histogram, labelled by id and status
+	MetricHandlerErrors    = "startup_handler_errors_total"        // Counter, labelled by id
+	MetricRequestTimeouts  = "startup_send_request_timeouts_total" // Counter, labelled by id
+)
+
+// Label qualifies a metric
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Metrics receives the measurements made by the runtime and by Identities, allowing them to be
+// forwarded to any metrics library.  Implementations must be safe for concurrent use.
+type Metrics interface {
+	// IncCounter increments the named counter
+	IncCounter(name string, labels ...Label)
+	// ObserveDuration records a duration in the named histogram
+	ObserveDuration(name string, d time.Duration, labels ...Label)
+}
+
+// ErrMetricsMustNotBeNil raised if WithMetrics() is called with nil
+var ErrMetricsMustNotBeNil = errors.New("metrics must not be nil")
+
+// WithMetrics specifies where the metrics of the StartableFunctions, and of the Identities
+// registered on their behalf, are recorded.  The Metrics are also available to the
+// StartableFunctions via FunctionOptions.
+func WithMetrics(m Metrics) OptionSetter {
+	return func(o *Options) error {
+		if m == nil {
+			return ErrMetricsMustNotBeNil
+		}
+		o.Metrics = m
+		return nil
+	}
+}
+
+// noopMetrics discards all measurements
+type noopMetrics struct{}
+
+func (noopMetrics) IncCounter(string, ...Label)                     {}
+func (noopMetrics) ObserveDuration(string, time.Duration, ...Label) {}
+
+// metricsObserver is a LifecycleObserver that records the lifecycle of StartableFunctions as Metrics
+type metricsObserver struct {
+	NoopObserver
+	m Metrics
+}
+
+func (o metricsObserver) OnStarted(e StartedEvent) {
+	o.m.IncCounter(MetricFunctionStarts, Label{"function", e.Name})
+}
+
+func (o metricsObserver) OnExit(e ExitEvent) {
+	o.m.IncCounter(MetricFunctionExits, Label{"function", e.Exit.Name}, Label{"reason", e.Exit.Reason.String()})
+}
+
+func (o metricsObserver) OnPanic(e PanicEvent) {
+	o.m.IncCounter(MetricFunctionPanics, Label{"function", e.Name})
+}
+
+func (o metricsObserver) OnRestart(e RestartEvent) {
+	o.m.IncCounter(MetricFunctionRestarts, Label{"function", e.Name})
+}
+
+// DefaultBuckets are the upper bounds, in seconds, of the histogram buckets used by MemoryMetrics by default
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// MemoryMetrics is an in-memory implementation of Metrics, which can render its
+// measurements in the Prometheus text exposition format
+type MemoryMetrics struct {
+	lck        sync.Mutex
+	buckets    []float64
+	counters   map[string]map[string]*counterValue
+	histograms map[string]map[string]*histogramValue
+}
+
+type counterValue struct {
+	labels []Label
+	value  uint64
+}
+
+type histogramValue struct {
+	labels []Label
+	counts []uint64 // Count of observations within each bucket, non-cumulative
+	count  uint64
+	sum    float64
+}
+
+// NewMemoryMetrics returns an empty MemoryMetrics, with histograms using the specified
+// bucket upper bounds (in seconds), or DefaultBuckets if none are specified
+func NewMemoryMetrics(buckets ...float64) *MemoryMetrics {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+
+	return &MemoryMetrics{
+		buckets:    buckets,
+		counters:   map[string]map[string]*counterValue{},
+		histograms: map[string]map[string]*histogramValue{},
+	}
+}
+
+// sortLabels returns the labels ordered by name, together with a key identifying them
+func sortLabels(labels []Label) ([]Label, string) {
+	labels = slices.Clone(labels)
+	slices.SortFunc(labels, func(a, b Label) int { return strings.Compare(a.Name, b.Name) })
+	return labels, formatLabels(labels)
+}
+
+func (m *MemoryMetrics) IncCounter(name string, labels ...Label) {
+	labels, key := sortLabels(labels)
+
+	m.lck.Lock()
+	defer m.lck.Unlock()
+
+	values, ok := m.counters[name]
+	if !ok {
+		values = map[string]*counterValue{}
+		m.counters[name] = values
+	}
+	v, ok := values[key]
+	if !ok {
+		v = &counterValue{labels: labels}
+		values[key] = v
+	}
+	v.value++
+}
+
+func (m *MemoryMetrics) ObserveDuration(name string, d time.Duration, labels ...Label) {
+	labels, key := sortLabels(labels)
+	secs := d.Seconds()
+
+	m.lck.Lock()
+	defer m.lck.Unlock()
+
+	values, ok := m.histograms[name]
+	if !ok {
+		values = map[string]*histogramValue{}
+		m.histograms[name] = values
+	}
+	v, ok := values[key]
+	if !ok {
+		v = &histogramValue{labels: labels, counts: make([]uint64, len(m.buckets))}
+		values[key] = v
+	}
+
+	if i, _ := slices.BinarySearch(m.buckets, secs); i < len(m.buckets) {
+		v.counts[i]++
+	}
+	v.count++
+	v.sum += secs
+}
+
+// Counter returns the current value of the named counter with the specified labels
+func (m *MemoryMetrics) Counter(name string, labels ...Label) uint64 {
+	_, key := sortLabels(labels)
+
+	m.lck.Lock()
+	defer m.lck.Unlock()
+
+	if v, ok := m.counters[name][key]; ok {
+		return v.value
+	}
+	return 0
+}
+
+// Histogram returns the number of observations, and their sum in seconds,
+// of the named histogram with the specified labels
+func (m *MemoryMetrics) Histogram(name string, labels ...Label) (count uint64, sum float64) {
+	_, key := sortLabels(labels)
+
+	m.lck.Lock()
+	defer m.lck.Unlock()
+
+	if v, ok := m.histograms[name][key]; ok {
+		return v.count, v.sum
+	}
+	return 0, 0
+}
+
+// WritePrometheus writes all the metrics in the Prometheus text exposition format,
+// ordered by name and then by labels
+func (m *MemoryMetrics) WritePrometheus(w io.Writer) error {
+	m.lck.Lock()
+	defer m.lck.Unlock()
+
+	var b strings.Builder
+
+	for _, name := range slices.Sorted(maps.Keys(m.counters)) {
+		fmt.Fprintf(&b, "# TYPE %s counter\n", name)
+		values := m.counters[name]
+		for _, key := range slices.Sorted(maps.Keys(values)) {
+			fmt.Fprintf(&b, "%s%s %d\n", name, key, values[key].value)
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(m.histograms)) {
+		fmt.Fprintf(&b, "# TYPE %s histogram\n", name)
+		values := m.histograms[name]
+		for _, key := range slices.Sorted(maps.Keys(values)) {
+			v := values[key]
+
+			var cumulative uint64
+			for i, le := range m.buckets {
+				cumulative += v.counts[i]
+				fmt.Fprintf(&b, "%s_bucket%s %d\n", name, formatLabels(append(slices.Clone(v.labels), Label{"le", formatFloat(le)})), cumulative)
+			}
+			fmt.Fprintf(&b, "%s_bucket%s %d\n", name, formatLabels(append(slices.Clone(v.labels), Label{"le", "+Inf"})), v.count)
+			fmt.Fprintf(&b, "%s_sum%s %s\n", name, key, formatFloat(v.sum))
+			fmt.Fprintf(&b, "%s_count%s %d\n", name, key, v.count)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// ServeHTTP allows the MemoryMetrics to be scraped by Prometheus
+func (m *MemoryMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WritePrometheus(w)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// formatLabels renders the labels as they appear in the Prometheus text exposition format
+func formatLabels(labels []Label) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	parts := make([]string, 0, len(labels))
+	for _, l := range labels {
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, l.Name, labelEscaper.Replace(l.Value)))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// statusLabel describes the Status of a Res for use as a label
+func statusLabel(s Status) string {
+	switch s {
+	case Success:
+		return "success"
+	case Error:
+		return "error"
+	case RequestTimeout:
+		return "timeout"
+	default:
+		return "unknown"
+	}
+}
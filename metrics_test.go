@@ -0,0 +1,140 @@
+package startup
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func ExampleMemoryMetrics_WritePrometheus() {
+
+	m := NewMemoryMetrics(0.1, 1)
+
+	m.IncCounter(MetricFunctionStarts, Label{"function", "Foo"})
+	m.IncCounter(MetricFunctionStarts, Label{"function", "Foo"})
+	m.ObserveDuration(MetricShutdownDuration, 500*time.Millisecond)
+
+	m.WritePrometheus(os.Stdout)
+	// Output:
+	// # TYPE startup_function_starts_total counter
+	// startup_function_starts_total{function="Foo"} 2
+	// # TYPE startup_shutdown_duration_seconds histogram
+	// startup_shutdown_duration_seconds_bucket{le="0.1"} 0
+	// startup_shutdown_duration_seconds_bucket{le="1"} 1
+	// startup_shutdown_duration_seconds_bucket{le="+Inf"} 1
+	// startup_shutdown_duration_seconds_sum 0.5
+	// startup_shutdown_duration_seconds_count 1
+}
+
+func TestMemoryMetrics_Labels(t *testing.T) {
+
+	m := NewMemoryMetrics()
+
+	m.IncCounter("requests_total", Label{"b", "2"}, Label{"a", "say \"hi\"\n"})
+
+	if n := m.Counter("requests_total", Label{"a", "say \"hi\"\n"}, Label{"b", "2"}); n != 1 {
+		t.Fatalf("expected counter to be independent of label order, got: %d", n)
+	}
+
+	var b strings.Builder
+	m.WritePrometheus(&b)
+
+	if !strings.Contains(b.String(), `requests_total{a="say \"hi\"\n",b="2"} 1`) {
+		t.Fatalf("expected escaped and ordered labels, got: %s", b.String())
+	}
+}
+
+func TestWithMetrics(t *testing.T) {
+
+	var runs int
+
+	myFunc := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		runs++
+		if runs == 1 {
+			panic("Boom!")
+		}
+	}
+
+	m := NewMemoryMetrics()
+
+	StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{
+			Name:          "Foo",
+			Func:          myFunc,
+			RestartPolicy: RestartPolicy{Mode: RestartOnPanic, InitialBackoff: time.Millisecond},
+		},
+	}, WithMetrics(m))
+
+	foo := Label{"function", "Foo"}
+
+	if n := m.Counter(MetricFunctionStarts, foo); n != 2 {
+		t.Fatalf("expected 2 starts, got: %d", n)
+	}
+	if n := m.Counter(MetricFunctionPanics, foo); n != 1 {
+		t.Fatalf("expected 1 panic, got: %d", n)
+	}
+	if n := m.Counter(MetricFunctionRestarts, foo); n != 1 {
+		t.Fatalf("expected 1 restart, got: %d", n)
+	}
+	if n := m.Counter(MetricFunctionExits, foo, Label{"reason", "returned"}); n != 1 {
+		t.Fatalf("expected 1 exit, got: %d", n)
+	}
+	if n, _ := m.Histogram(MetricShutdownDuration); n != 1 {
+		t.Fatalf("expected 1 shutdown, got: %d", n)
+	}
+}
+
+func TestWithMetrics_Identity(t *testing.T) {
+
+	bobHandler := func(ctx context.Context, req *Req, res *Res) {
+		res.Status = Error
+		res.Error = errors.New("unsupported")
+	}
+	bob := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		<-ctx.Done()
+	}
+	alice := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		c, err := opts.Identity.Connect(ctx, "Bob", WithConnectDiscoveryService(opts.DiscoveryService))
+		if err != nil {
+			panic(err)
+		}
+		opts.Identity.Send(ctx, &Req{Type: "text"}, c.ReqChan)
+		opts.Identity.Send(ctx, &Req{Type: "text"}, c.ReqChan, WithSendTimeout(time.Nanosecond))
+	}
+
+	m := NewMemoryMetrics()
+
+	StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{Name: "Bob", Func: bob, Handler: bobHandler},
+		{Name: "Alice", Func: alice, RegisterWithDiscoveryService: true},
+	}, WithMetrics(m), WithTimeout(time.Second))
+
+	if n, _ := m.Histogram(MetricConnectDuration, Label{"id", "Bob"}, Label{"result", "ok"}); n != 1 {
+		t.Fatalf("expected 1 connect, got: %d", n)
+	}
+	if n, _ := m.Histogram(MetricSendDuration, Label{"id", "Alice"}, Label{"status", "error"}); n != 1 {
+		t.Fatalf("expected 1 send returning an error, got: %d", n)
+	}
+	if n := m.Counter(MetricHandlerErrors, Label{"id", "Bob"}); n < 1 {
+		t.Fatalf("expected handler errors, got: %d", n)
+	}
+	if n := m.Counter(MetricRequestTimeouts, Label{"id", "Alice"}); n != 1 {
+		t.Fatalf("expected 1 request timeout, got: %d", n)
+	}
+}
+
+func TestWithMetrics_Nil(t *testing.T) {
+
+	myFunc := func(ctx context.Context, opts *FunctionOptions, args ...any) {}
+
+	err := StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{Func: myFunc},
+	}, WithMetrics(nil))
+
+	if err != ErrMetricsMustNotBeNil {
+		t.Fatalf("Expected error: ErrMetricsMustNotBeNil, got: %v", err)
+	}
+}
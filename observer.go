@@ -0,0 +1,125 @@
+package startup
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// StartingEvent is raised when a StartableFunction is launched, before it waits for its
+// dependencies and is registered with the DiscoveryService
+type StartingEvent struct {
+	Name      string
+	DependsOn []string
+	Time      time.Time
+}
+
+// StartedEvent is raised each time a StartableFunction is executed, with Run counting
+// from 1 and increasing with each restart
+type StartedEvent struct {
+	Name     string
+	Identity Identity
+	Run      int
+	Time     time.Time
+}
+
+// ReadyEvent is raised when a StartableFunction declares itself ready, or returns without error
+type ReadyEvent struct {
+	Name string
+	Time time.Time
+}
+
+// ExitEvent is raised when a StartableFunction has exited and will not be restarted.
+// Duration is measured from when the StartableFunction was launched.
+type ExitEvent struct {
+	Exit     FunctionExit
+	Duration time.Duration
+	Time     time.Time
+}
+
+// PanicEvent is raised each time a StartableFunction panics, whether or not it is then restarted
+type PanicEvent struct {
+	Name string
+	Err  *PanicError
+	Time time.Time
+}
+
+// ShutdownBeginEvent is raised when shutdown begins, describing what triggered it
+type ShutdownBeginEvent struct {
+	Trigger FunctionExit
+	Time    time.Time
+}
+
+// ShutdownTimeoutEvent is raised if StartableFunctions did not exit within Options.Timeout
+type ShutdownTimeoutEvent struct {
+	Abandoned []string
+	Timeout   time.Duration
+	Time      time.Time
+}
+
+// RestartEvent is raised when a StartableFunction is about to be restarted, after the Backoff.
+// Restart counts from 1, and Err is the error of the run that exited, if any.
+type RestartEvent struct {
+	Name    string
+	Restart int
+	Backoff time.Duration
+	Err     error
+	Time    time.Time
+}
+
+// LifecycleObserver is notified of the lifecycle transitions of the StartableFunctions.
+// Methods are called synchronously by the goroutine managing the transition, so must return
+// promptly, and may be called concurrently for different StartableFunctions.
+// Embed NoopObserver to implement only the methods of interest.
+type LifecycleObserver interface {
+	OnStarting(StartingEvent)
+	OnStarted(StartedEvent)
+	OnReady(ReadyEvent)
+	OnExit(ExitEvent)
+	OnPanic(PanicEvent)
+	OnShutdownBegin(ShutdownBeginEvent)
+	OnShutdownTimeout(ShutdownTimeoutEvent)
+	OnRestart(RestartEvent)
+}
+
+// NoopObserver is a LifecycleObserver that ignores all events
+type NoopObserver struct{}
+
+func (NoopObserver) OnStarting(StartingEvent)               {}
+func (NoopObserver) OnStarted(StartedEvent)                 {}
+func (NoopObserver) OnReady(ReadyEvent)                     {}
+func (NoopObserver) OnExit(ExitEvent)                       {}
+func (NoopObserver) OnPanic(PanicEvent)                     {}
+func (NoopObserver) OnShutdownBegin(ShutdownBeginEvent)     {}
+func (NoopObserver) OnShutdownTimeout(ShutdownTimeoutEvent) {}
+func (NoopObserver) OnRestart(RestartEvent)                 {}
+
+// ErrObserverMustNotBeNil raised if WithObserver() is called with nil
+var ErrObserverMustNotBeNil = errors.New("observer must not be nil")
+
+// WithObserver adds a LifecycleObserver, which is notified of lifecycle transitions
+// in the order that observers are added
+func WithObserver(obs LifecycleObserver) OptionSetter {
+	return func(o *Options) error {
+		if obs == nil {
+			return ErrObserverMustNotBeNil
+		}
+		o.Observers = append(o.Observers[:len(o.Observers):len(o.Observers)], obs)
+		return nil
+	}
+}
+
+// notify calls fn for each LifecycleObserver, logging rather than propagating any panic,
+// so that a faulty observer cannot disrupt the StartableFunctions
+func (f *funcMgr) notify(fn func(LifecycleObserver)) {
+	for _, obs := range f.o.Observers {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					f.logPanic(fmt.Errorf("caught unhandled panic in LifecycleObserver: %v", r), "observer_panicked")
+				}
+			}()
+			fn(obs)
+		}()
+	}
+}
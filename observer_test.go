@@ -0,0 +1,131 @@
+package startup
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingObserver records the events it receives, in order
+type recordingObserver struct {
+	NoopObserver
+	lck    sync.Mutex
+	events []string
+}
+
+func (r *recordingObserver) record(format string, args ...any) {
+	r.lck.Lock()
+	defer r.lck.Unlock()
+	r.events = append(r.events, fmt.Sprintf(format, args...))
+}
+
+func (r *recordingObserver) OnStarting(e StartingEvent) { r.record("starting %s", e.Name) }
+func (r *recordingObserver) OnStarted(e StartedEvent)   { r.record("started %s run %d", e.Name, e.Run) }
+func (r *recordingObserver) OnReady(e ReadyEvent)       { r.record("ready %s", e.Name) }
+func (r *recordingObserver) OnPanic(e PanicEvent)       { r.record("panic %s: %v", e.Name, e.Err.Value) }
+func (r *recordingObserver) OnRestart(e RestartEvent)   { r.record("restart %s %d", e.Name, e.Restart) }
+func (r *recordingObserver) OnExit(e ExitEvent)         { r.record("exit %s %s", e.Exit.Name, e.Exit.Reason) }
+func (r *recordingObserver) OnShutdownBegin(e ShutdownBeginEvent) {
+	r.record("shutdown by %s %s", e.Trigger.Name, e.Trigger.Reason)
+}
+func (r *recordingObserver) OnShutdownTimeout(e ShutdownTimeoutEvent) {
+	r.record("abandoned %v", e.Abandoned)
+}
+
+func ExampleWithObserver() {
+
+	var runs int
+
+	myFunc := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		runs++
+		if runs == 1 {
+			panic("Boom!")
+		}
+		opts.Ready()
+	}
+
+	obs := &recordingObserver{}
+
+	StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{
+			Name:          "Foo",
+			Func:          myFunc,
+			RestartPolicy: RestartPolicy{Mode: RestartOnPanic, InitialBackoff: time.Millisecond},
+		},
+	}, WithObserver(obs))
+
+	for _, e := range obs.events {
+		fmt.Println(e)
+	}
+	// Output:
+	// starting Foo
+	// started Foo run 1
+	// panic Foo: Boom!
+	// restart Foo 1
+	// started Foo run 2
+	// ready Foo
+	// exit Foo returned
+	// shutdown by Foo returned
+}
+
+func TestWithObserver_ShutdownTimeout(t *testing.T) {
+
+	release := make(chan struct{})
+	defer close(release)
+
+	stubborn := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		<-release
+	}
+
+	obs := &recordingObserver{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	StartNamedFunctions(ctx, []FunctionDeclaration{
+		{Name: "Stubborn", Func: stubborn},
+	}, WithObserver(obs), WithTimeout(10*time.Millisecond))
+
+	obs.lck.Lock()
+	defer obs.lck.Unlock()
+
+	expected := []string{"starting Stubborn", "started Stubborn run 1", "shutdown by  cancelled", "abandoned [Stubborn]"}
+	if fmt.Sprint(obs.events) != fmt.Sprint(expected) {
+		t.Fatalf("expected events %v, got: %v", expected, obs.events)
+	}
+}
+
+// panickingObserver panics on every event
+type panickingObserver struct {
+	NoopObserver
+}
+
+func (panickingObserver) OnStarting(StartingEvent) { panic("faulty observer") }
+
+func TestWithObserver_Panic(t *testing.T) {
+
+	myFunc := func(ctx context.Context, opts *FunctionOptions, args ...any) {}
+
+	err := StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{Func: myFunc},
+	}, WithObserver(panickingObserver{}))
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestWithObserver_Nil(t *testing.T) {
+
+	myFunc := func(ctx context.Context, opts *FunctionOptions, args ...any) {}
+
+	err := StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{Func: myFunc},
+	}, WithObserver(nil))
+
+	if err != ErrObserverMustNotBeNil {
+		t.Fatalf("Expected error: ErrObserverMustNotBeNil, got: %v", err)
+	}
+}
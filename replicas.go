@@ -0,0 +1,163 @@
+package startup
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidReplicas is raised if the number of replicas is negative
+var ErrInvalidReplicas = errors.New("replicas must not be negative")
+
+// ErrNotReplicated is raised if Runtime.Scale is called for a FunctionDeclaration without Replicas
+var ErrNotReplicated = errors.New("function is not replicated")
+
+// replicaName returns the name of the i'th replica of the replicated FunctionDeclaration
+func replicaName(group string, i int) string {
+	return fmt.Sprintf("%s-%d", group, i)
+}
+
+// replica returns the FunctionDeclaration of the i'th replica of the replicated FunctionDeclaration
+func (f FunctionDeclaration) replica(i int) FunctionDeclaration {
+	f.group = f.Name
+	f.Name = replicaName(f.Name, i)
+	f.Replicas = 0
+	return f
+}
+
+// expandReplicas replaces each replicated FunctionDeclaration with its replicas, and each dependency
+// on a replicated FunctionDeclaration with dependencies on its replicas, returning the replicated
+// FunctionDeclarations by Name.  The names of the replicas must be unique.
+func expandReplicas(fns []FunctionDeclaration, names map[string]bool) ([]FunctionDeclaration, map[string]FunctionDeclaration, error) {
+	pools := map[string]FunctionDeclaration{}
+	var expanded []FunctionDeclaration
+
+	for _, fn := range fns {
+		if fn.Replicas == 0 {
+			expanded = append(expanded, fn)
+			continue
+		}
+
+		pools[fn.Name] = fn
+		for i := range fn.Replicas {
+			r := fn.replica(i)
+			if names[r.Name] {
+				return nil, nil, fmt.Errorf("%w: %s", ErrNameAlreadyExists, r.Name)
+			}
+			names[r.Name] = true
+			expanded = append(expanded, r)
+		}
+	}
+
+	for i, fn := range expanded {
+		if !slices.ContainsFunc(fn.DependsOn, func(dep string) bool { _, ok := pools[dep]; return ok }) {
+			continue
+		}
+
+		var deps []string
+		for _, dep := range fn.DependsOn {
+			if pool, ok := pools[dep]; ok {
+				for j := range pool.Replicas {
+					deps = append(deps, replicaName(dep, j))
+				}
+			} else {
+				deps = append(deps, dep)
+			}
+		}
+		expanded[i].DependsOn = deps
+	}
+
+	return expanded, pools, nil
+}
+
+// replicas returns the fnEntries of the replicas of the replicated FunctionDeclaration, including any that have exited.
+// The caller must hold the lock
+func (f *funcMgr) replicas(group string) []*fnEntry {
+	var entries []*fnEntry
+	for _, e := range f.fns {
+		if e.group == group {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// addPool launches the replicas of a replicated FunctionDeclaration.  Should any replica fail to launch,
+// then those already launched are stopped and the FunctionDeclaration is forgotten, so that its name can be reused.
+func (f *funcMgr) addPool(fn FunctionDeclaration) error {
+	f.lck.Lock()
+	if _, ok := f.pools[fn.Name]; ok || f.find(fn.Name) != nil {
+		f.lck.Unlock()
+		return ErrNameAlreadyExists
+	}
+	f.pools[fn.Name] = fn
+	f.lck.Unlock()
+
+	if err := f.scale(fn.Name, fn.Replicas); err != nil {
+		f.scale(fn.Name, 0)
+
+		f.lck.Lock()
+		delete(f.pools, fn.Name)
+		f.lck.Unlock()
+		return err
+	}
+	return nil
+}
+
+// scale launches or stops replicas of the replicated FunctionDeclaration, so that n are running.
+// Replicas are launched with the lowest unused indices, and those with the highest indices are stopped first.
+func (f *funcMgr) scale(name string, n int) error {
+	if n < 0 {
+		return ErrInvalidReplicas
+	}
+
+	f.scaleLck.Lock()
+	defer f.scaleLck.Unlock()
+
+	f.lck.Lock()
+	pool, ok := f.pools[name]
+	exists := f.find(name) != nil
+	// Replicas that have exited, without triggering shutdown, are forgotten so that they can be replaced
+	f.fns = slices.DeleteFunc(f.fns, func(e *fnEntry) bool { return e.group == name && e.exited() })
+	running := map[int]bool{}
+	for _, e := range f.replicas(name) {
+		if i, err := strconv.Atoi(strings.TrimPrefix(e.name, name+"-")); err == nil {
+			running[i] = true
+		}
+	}
+	f.lck.Unlock()
+
+	if !ok {
+		if exists {
+			return fmt.Errorf("%w: %s", ErrNotReplicated, name)
+		}
+		return ErrFunctionNotFound
+	}
+
+	if len(running) != n {
+		f.logger(fmt.Sprintf("scaling %s from %d to %d replicas", name, len(running), n), "scaling",
+			LogKeyFunction, name, "replicas", n)
+	}
+
+	for i := 0; len(running) < n; i++ {
+		if running[i] {
+			continue
+		}
+		if err := f.addFn(pool.replica(i)); err != nil {
+			return err
+		}
+		running[i] = true
+	}
+
+	indices := slices.Sorted(maps.Keys(running))
+	for _, i := range slices.Backward(indices[min(n, len(indices)):]) {
+		if err := f.stopFn(replicaName(name, i)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,247 @@
+package startup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func ExampleFunctionDeclaration_replicas() {
+
+	var lck sync.Mutex
+	var names []string
+
+	consumer := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		lck.Lock()
+		names = append(names, fmt.Sprintf("%s (%s)", opts.Self, opts.Group))
+		lck.Unlock()
+		<-ctx.Done()
+	}
+
+	r, err := Start(context.Background(), []FunctionDeclaration{
+		{Name: "Consumer", Func: consumer, Replicas: 3},
+	})
+	if err != nil {
+		panic(err)
+	}
+	r.Shutdown(context.Background())
+
+	sort.Strings(names)
+	fmt.Println(names)
+	// Output:
+	// [Consumer-0 (Consumer) Consumer-1 (Consumer) Consumer-2 (Consumer)]
+}
+
+func TestReplicas_DiscoveryGroup(t *testing.T) {
+
+	got := make(chan []string, 1)
+
+	consumer := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		opts.Ready()
+		<-ctx.Done()
+	}
+	producer := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		ids, err := opts.DiscoveryService.FindGroup("Consumer")
+		if err != nil {
+			panic(err)
+		}
+		got <- ids
+	}
+
+	StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{Name: "Consumer", Func: consumer, Replicas: 2, RegisterWithDiscoveryService: true},
+		{Name: "Producer", Func: producer, DependsOn: []string{"Consumer"}},
+	})
+
+	if ids := <-got; !slices.Equal(ids, []string{"Consumer-0", "Consumer-1"}) {
+		t.Fatalf("expected both replicas to be registered in the group, got: %v", ids)
+	}
+}
+
+// running returns the names of the running replicas of the group
+func running(r *Runtime, group string) []string {
+	r.f.lck.Lock()
+	defer r.f.lck.Unlock()
+
+	var names []string
+	for _, e := range r.f.replicas(group) {
+		if !e.exited() {
+			names = append(names, e.name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestRuntime_Scale(t *testing.T) {
+
+	consumer := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		<-ctx.Done()
+	}
+
+	r, err := Start(context.Background(), []FunctionDeclaration{
+		{Name: "Consumer", Func: consumer, Replicas: 2, RegisterWithDiscoveryService: true},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer r.Shutdown(context.Background())
+
+	if err := r.Scale("Consumer", 4); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if names := running(r, "Consumer"); !slices.Equal(names, []string{"Consumer-0", "Consumer-1", "Consumer-2", "Consumer-3"}) {
+		t.Fatalf("expected 4 replicas, got: %v", names)
+	}
+
+	// Gaps are filled before further indices are used
+	if err := r.Stop("Consumer-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.Scale("Consumer", 4); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if names := running(r, "Consumer"); !slices.Equal(names, []string{"Consumer-0", "Consumer-1", "Consumer-2", "Consumer-3"}) {
+		t.Fatalf("expected Consumer-1 to be replaced, got: %v", names)
+	}
+
+	if err := r.Scale("Consumer", 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if names := running(r, "Consumer"); !slices.Equal(names, []string{"Consumer-0"}) {
+		t.Fatalf("expected 1 replica, got: %v", names)
+	}
+	if ids, _ := r.f.funcOps.DiscoveryService.FindGroup("Consumer"); !slices.Equal(ids, []string{"Consumer-0"}) {
+		t.Fatalf("expected stopped replicas to be deregistered, got: %v", ids)
+	}
+
+	// Scaling to zero does not trigger shutdown
+	if err := r.Scale("Consumer", 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	select {
+	case <-r.Done():
+		t.Fatal("expected scaling to zero not to trigger shutdown")
+	default:
+	}
+}
+
+func TestRuntime_ScaleExited(t *testing.T) {
+
+	var launches atomic.Int32
+
+	// The first run of Worker-1 returns, without triggering shutdown
+	worker := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		if launches.Add(1) <= 2 && opts.Self == "Worker-1" {
+			return
+		}
+		<-ctx.Done()
+	}
+
+	r, err := Start(context.Background(), []FunctionDeclaration{
+		{Name: "Worker", Func: worker, Replicas: 2, ExitBehaviour: NeverShutdown},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer r.Shutdown(context.Background())
+
+	deadline := time.Now().Add(time.Second)
+	for !slices.Equal(running(r, "Worker"), []string{"Worker-0"}) {
+		if time.Now().After(deadline) {
+			t.Fatal("expected Worker-1 to exit")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// The exited replica is replaced, rather than counted as running
+	if err := r.Scale("Worker", 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if names := running(r, "Worker"); !slices.Equal(names, []string{"Worker-0", "Worker-1"}) {
+		t.Fatalf("expected Worker-1 to be replaced, got: %v", names)
+	}
+	if n := launches.Load(); n != 3 {
+		t.Fatalf("expected 3 launches, got: %d", n)
+	}
+}
+
+func TestRuntime_AddReplicas(t *testing.T) {
+
+	fn := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		<-ctx.Done()
+	}
+
+	r, err := Start(context.Background(), []FunctionDeclaration{
+		{Name: "Foo", Func: fn},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer r.Shutdown(context.Background())
+
+	if err := r.Add(FunctionDeclaration{Name: "Worker", Func: fn, Replicas: 2}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if names := running(r, "Worker"); !slices.Equal(names, []string{"Worker-0", "Worker-1"}) {
+		t.Fatalf("expected 2 replicas, got: %v", names)
+	}
+	if err := r.Add(FunctionDeclaration{Name: "Worker", Func: fn}); err != ErrNameAlreadyExists {
+		t.Fatalf("Expected error: ErrNameAlreadyExists, got: %v", err)
+	}
+	if err := r.Scale("Foo", 2); !errors.Is(err, ErrNotReplicated) {
+		t.Fatalf("Expected error: ErrNotReplicated, got: %v", err)
+	}
+	if err := r.Scale("Bar", 2); err != ErrFunctionNotFound {
+		t.Fatalf("Expected error: ErrFunctionNotFound, got: %v", err)
+	}
+	if err := r.Scale("Worker", -1); err != ErrInvalidReplicas {
+		t.Fatalf("Expected error: ErrInvalidReplicas, got: %v", err)
+	}
+
+	// A failed Add leaves nothing behind, so the name can be reused
+	if err := r.Add(FunctionDeclaration{Name: "Pool", Func: fn, Replicas: 2, DependsOn: []string{"Missing"}}); !errors.Is(err, ErrUnknownDependency) {
+		t.Fatalf("Expected error: ErrUnknownDependency, got: %v", err)
+	}
+	if err := r.Add(FunctionDeclaration{Name: "Pool-1", Func: fn}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.Add(FunctionDeclaration{Name: "Pool", Func: fn, Replicas: 2}); err != ErrNameAlreadyExists {
+		t.Fatalf("Expected error: ErrNameAlreadyExists, got: %v", err)
+	}
+	if names := running(r, "Pool"); len(names) != 0 {
+		t.Fatalf("expected launched replicas to be stopped, got: %v", names)
+	}
+	if err := r.Stop("Pool-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.Add(FunctionDeclaration{Name: "Pool", Func: fn, Replicas: 2}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestReplicas_Invalid(t *testing.T) {
+
+	fn := func(ctx context.Context, opts *FunctionOptions, args ...any) {}
+
+	err := StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{Name: "Foo", Func: fn, Replicas: -1},
+	})
+	if err != ErrInvalidReplicas {
+		t.Fatalf("Expected error: ErrInvalidReplicas, got: %v", err)
+	}
+
+	err = StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{Name: "Foo", Func: fn, Replicas: 2},
+		{Name: "Foo-1", Func: fn},
+	})
+	if !errors.Is(err, ErrNameAlreadyExists) {
+		t.Fatalf("Expected error: ErrNameAlreadyExists, got: %v", err)
+	}
+}
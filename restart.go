@@ -0,0 +1,144 @@
+package startup
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// RestartMode determines whether a StartableFunction is restarted in place when it exits
+type RestartMode int
+
+const (
+	// RestartNever means any exit of the StartableFunction triggers shutdown (default)
+	RestartNever RestartMode = iota
+	// RestartOnPanic means the StartableFunction is restarted only if it exits due to an unhandled panic
+	RestartOnPanic
+	// RestartAlways means the StartableFunction is restarted whenever it exits, unless shutdown has begun
+	RestartAlways
+	// RestartOnFailure means the StartableFunction is restarted if it exits due to an unhandled panic,
+	// or if it is an ErrStartableFunction that returns an error
+	RestartOnFailure
+)
+
+// RestartPolicy describes how a StartableFunction is restarted after it exits.
+// Each restart is made with a fresh context, after a backoff that grows exponentially
+// with the number of restarts made within Window.  Once MaxRestarts is exceeded within
+// Window, the StartableFunction is no longer restarted and the normal shutdown cascade applies.
+type RestartPolicy struct {
+	// Mode determines which exits result in a restart (default is RestartNever)
+	Mode RestartMode
+	// MaxRestarts is the number of restarts allowed within Window (default is 5).
+	// A negative value allows unlimited restarts.
+	MaxRestarts int
+	// Window is the period over which restarts are counted (default is 1 minute)
+	Window time.Duration
+	// InitialBackoff is the pause before the first restart (default is 100 milliseconds)
+	InitialBackoff time.Duration
+	// MaxBackoff caps the pause before any restart (default is 30 seconds)
+	MaxBackoff time.Duration
+	// Multiplier is applied to the backoff for each successive restart (default is 2)
+	Multiplier float64
+	// Jitter randomises each backoff by up to this fraction of its value, and must be in the range [0,1]
+	Jitter float64
+}
+
+// ErrInvalidRestartPolicy is raised if a RestartPolicy has negative durations, a MaxBackoff below its InitialBackoff,
+// a Multiplier below 1 (other than zero, which selects the default) or Jitter outside the range [0,1]
+var ErrInvalidRestartPolicy = errors.New("invalid restart policy")
+
+// ErrRestartLimitReached is raised when a StartableFunction has exhausted the restarts allowed by its RestartPolicy
+var ErrRestartLimitReached = errors.New("restart limit reached")
+
+func (p RestartPolicy) validate() error {
+	if p.Mode < RestartNever || p.Mode > RestartOnFailure {
+		return ErrInvalidRestartPolicy
+	}
+	if p.Window < 0 || p.InitialBackoff < 0 || p.MaxBackoff < 0 {
+		return ErrInvalidRestartPolicy
+	}
+	if d := p.withDefaults(); d.MaxBackoff < d.InitialBackoff {
+		return ErrInvalidRestartPolicy
+	}
+	if p.Multiplier != 0 && p.Multiplier < 1 {
+		return ErrInvalidRestartPolicy
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return ErrInvalidRestartPolicy
+	}
+	return nil
+}
+
+// withDefaults returns a copy of the RestartPolicy with unset values replaced by their defaults
+func (p RestartPolicy) withDefaults() RestartPolicy {
+	if p.MaxRestarts == 0 {
+		p.MaxRestarts = 5
+	}
+	if p.Window == 0 {
+		p.Window = time.Minute
+	}
+	if p.InitialBackoff == 0 {
+		p.InitialBackoff = 100 * time.Millisecond
+	}
+	if p.MaxBackoff == 0 {
+		p.MaxBackoff = 30 * time.Second
+	}
+	if p.Multiplier == 0 {
+		p.Multiplier = 2
+	}
+	return p
+}
+
+// shouldRestart reports whether an exit with the specified error warrants a restart
+func (p RestartPolicy) shouldRestart(err error) bool {
+	switch p.Mode {
+	case RestartAlways:
+		return true
+	case RestartOnPanic:
+		var pe *PanicError
+		return errors.As(err, &pe)
+	case RestartOnFailure:
+		return err != nil
+	default:
+		return false
+	}
+}
+
+// restartTracker records the restarts made under a RestartPolicy, to enforce
+// its restart budget and calculate the backoff before each restart
+type restartTracker struct {
+	p        RestartPolicy
+	restarts []time.Time
+}
+
+func newRestartTracker(p RestartPolicy) *restartTracker {
+	return &restartTracker{
+		p: p.withDefaults(),
+	}
+}
+
+// next records a restart at the specified time, returning the backoff to apply before
+// the restart, or false if the restart budget has been exhausted
+func (r *restartTracker) next(now time.Time) (time.Duration, bool) {
+	// Discard restarts that have fallen outside of the window
+	cutoff := now.Add(-r.p.Window)
+	i := 0
+	for i < len(r.restarts) && !r.restarts[i].After(cutoff) {
+		i++
+	}
+	r.restarts = r.restarts[i:]
+
+	if r.p.MaxRestarts >= 0 && len(r.restarts) >= r.p.MaxRestarts {
+		return 0, false
+	}
+
+	d := min(float64(r.p.InitialBackoff)*math.Pow(r.p.Multiplier, float64(len(r.restarts))), float64(r.p.MaxBackoff))
+	if r.p.Jitter > 0 {
+		// Capped again after jitter, so that MaxBackoff always holds
+		d = min(d+d*r.p.Jitter*(2*rand.Float64()-1), float64(r.p.MaxBackoff))
+	}
+
+	r.restarts = append(r.restarts, now)
+	return time.Duration(d), true
+}
@@ -0,0 +1,212 @@
+package startup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func ExampleRestartPolicy() {
+
+	var runs int
+
+	flakyConsumer := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		runs++
+		if runs < 3 {
+			panic("lost connection")
+		}
+		fmt.Printf("%s succeeded on run %d\n", opts.Self, runs)
+	}
+
+	StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{
+			Name: "Consumer",
+			Func: flakyConsumer,
+			RestartPolicy: RestartPolicy{
+				Mode:           RestartOnPanic,
+				InitialBackoff: time.Millisecond,
+			},
+		},
+	})
+
+	// Output:
+	// Consumer succeeded on run 3
+}
+
+func TestRestartPolicy_Exhausted(t *testing.T) {
+
+	var runs atomic.Int32
+	var otherExited atomic.Bool
+
+	flaky := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		runs.Add(1)
+		panic("Boom!")
+	}
+
+	other := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		<-ctx.Done()
+		otherExited.Store(true)
+	}
+
+	err := StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{
+			Name: "Flaky",
+			Func: flaky,
+			RestartPolicy: RestartPolicy{
+				Mode:           RestartOnPanic,
+				MaxRestarts:    2,
+				InitialBackoff: time.Millisecond,
+			},
+		},
+		{
+			Name: "Other",
+			Func: other,
+		},
+	}, WithTimeout(time.Second))
+
+	if !errors.Is(err, ErrRestartLimitReached) {
+		t.Fatalf("Expected error: ErrRestartLimitReached, got: %v", err)
+	}
+	if n := runs.Load(); n != 3 {
+		t.Fatalf("expected 3 runs (1 start and 2 restarts), got: %d", n)
+	}
+	if !otherExited.Load() {
+		t.Fatal("expected shutdown to cascade once restarts were exhausted")
+	}
+}
+
+func TestRestartPolicy_FreshContext(t *testing.T) {
+
+	var prev context.Context
+	var runs int
+
+	fn := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		runs++
+		if prev == ctx {
+			t.Error("expected a fresh context on restart")
+		}
+		prev = ctx
+	}
+
+	StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{
+			Func: fn,
+			RestartPolicy: RestartPolicy{
+				Mode:           RestartAlways,
+				MaxRestarts:    1,
+				InitialBackoff: time.Millisecond,
+			},
+		},
+	})
+
+	if runs != 2 {
+		t.Fatalf("expected 2 runs, got: %d", runs)
+	}
+}
+
+func TestRestartPolicy_Invalid(t *testing.T) {
+
+	err := StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{
+			Func:          func(ctx context.Context, opts *FunctionOptions, args ...any) {},
+			RestartPolicy: RestartPolicy{Mode: RestartAlways, Jitter: 2},
+		},
+	})
+
+	if err != ErrInvalidRestartPolicy {
+		t.Fatalf("Expected error: ErrInvalidRestartPolicy, got: %v", err)
+	}
+
+	err = StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{
+			Func:          func(ctx context.Context, opts *FunctionOptions, args ...any) {},
+			RestartPolicy: RestartPolicy{Mode: RestartAlways, InitialBackoff: time.Second, MaxBackoff: time.Millisecond},
+		},
+	})
+
+	if err != ErrInvalidRestartPolicy {
+		t.Fatalf("Expected error: ErrInvalidRestartPolicy, got: %v", err)
+	}
+}
+
+func TestRestartTracker_JitterCapped(t *testing.T) {
+
+	rt := newRestartTracker(RestartPolicy{
+		Mode:           RestartAlways,
+		MaxRestarts:    -1,
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     10 * time.Millisecond,
+		Jitter:         1,
+	})
+
+	now := time.Now()
+	for i := range 100 {
+		if d, _ := rt.next(now); d > 10*time.Millisecond {
+			t.Fatalf("restart %d: expected backoff to be capped at MaxBackoff, got: %v", i, d)
+		}
+	}
+}
+
+func TestRestartTracker(t *testing.T) {
+
+	rt := newRestartTracker(RestartPolicy{
+		Mode:           RestartAlways,
+		MaxRestarts:    3,
+		Window:         time.Minute,
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     25 * time.Millisecond,
+	})
+
+	now := time.Now()
+	for i, expected := range []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 25 * time.Millisecond} {
+		d, ok := rt.next(now)
+		if !ok {
+			t.Fatalf("restart %d: unexpectedly exhausted", i)
+		}
+		if d != expected {
+			t.Fatalf("restart %d: expected backoff %v, got: %v", i, expected, d)
+		}
+	}
+
+	if _, ok := rt.next(now); ok {
+		t.Fatal("expected restart budget to be exhausted")
+	}
+
+	// Budget recovers once the earlier restarts fall outside the window
+	if d, ok := rt.next(now.Add(2 * time.Minute)); !ok || d != 10*time.Millisecond {
+		t.Fatalf("expected budget to recover with initial backoff, got: %v, %v", d, ok)
+	}
+}
+
+func TestRestartPolicy_OnFailure(t *testing.T) {
+
+	var runs int
+
+	fn := func(ctx context.Context, opts *FunctionOptions, args ...any) error {
+		runs++
+		if runs < 3 {
+			return errors.New("not yet")
+		}
+		return nil
+	}
+
+	err := StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{
+			ErrFunc: fn,
+			RestartPolicy: RestartPolicy{
+				Mode:           RestartOnFailure,
+				InitialBackoff: time.Millisecond,
+			},
+		},
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if runs != 3 {
+		t.Fatalf("expected 3 runs, got: %d", runs)
+	}
+}
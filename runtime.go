@@ -0,0 +1,214 @@
+package startup
+
+import (
+	"context"
+	"io"
+	"slices"
+)
+
+// Runtime is a handle to the StartableFunctions launched by Start, allowing their
+// shutdown to be requested and awaited
+type Runtime struct {
+	f      *funcMgr
+	done   chan struct{}
+	report *ExitReport
+	err    error
+}
+
+// Start launches the StartableFunctions defined in the FunctionDeclarations in the same way as
+// StartNamedFunctions, but returns as soon as they have been launched.  StartableFunctions
+// with dependencies are launched after those dependencies, but only start once the
+// dependencies have declared themselves ready.  An error is returned
+// only if the FunctionDeclarations or Options are invalid, in which case nothing is launched.
+// The returned Runtime can then be used to request shutdown, and to wait for it to complete.
+func Start(ctx context.Context, funcs []FunctionDeclaration, opts ...OptionSetter) (*Runtime, error) {
+	if len(funcs) == 0 {
+		return nil, ErrMissingStartableFunctions
+	}
+
+	names := make(map[string]bool, len(funcs))
+
+	// Ensure name applied
+	var myFuncs []FunctionDeclaration
+	for _, fn := range funcs {
+		myFuncs = append(myFuncs, fn.withDefaults())
+	}
+
+	// Validate
+	for _, fn := range myFuncs {
+		if err := fn.validate(names); err != nil {
+			return nil, err
+		}
+	}
+
+	// Replicated functions are started as their replicas
+	myFuncs, pools, err := expandReplicas(myFuncs, names)
+	if err != nil {
+		return nil, err
+	}
+
+	// Functions are started after those they depend on
+	myFuncs, err = orderByDependencies(myFuncs)
+	if err != nil {
+		return nil, err
+	}
+
+	o := defaultOptions
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return nil, err
+		}
+	}
+
+	// Signals are checked once all the Options are applied, so that the order of the Options does not matter
+	if o.ReloadSignal != nil && slices.Contains(o.Signals, o.ReloadSignal) {
+		return nil, ErrInvalidReloadSignal
+	}
+
+	f := &funcMgr{
+		ctx:   ctx,
+		o:     o,
+		log:   o.newLogger(),
+		fns:   make([]*fnEntry, 0, len(myFuncs)),
+		pools: pools,
+	}
+
+	if !f.o.noDiscoveryService {
+		f.funcOps.DiscoveryService = NewDiscoveryService()
+		f.funcOps.registrationTTL = f.o.RegistrationTTL
+		f.funcOps.heartbeat = f.o.HeartbeatRenewal
+	}
+
+	// The lifecycle of the StartableFunctions is recorded by observing it
+	f.funcOps.Metrics = noopMetrics{}
+	if f.o.Metrics != nil {
+		f.funcOps.Metrics = f.o.Metrics
+		f.o.Observers = append(f.o.Observers[:len(f.o.Observers):len(f.o.Observers)], metricsObserver{m: f.o.Metrics})
+	}
+
+	// This context is used to prevent this function from exiting
+	// until a shutdown condition is met.
+	f.exitCtx, f.exitCancel = context.WithCancel(context.Background())
+
+	// Create a new cancellable context, which will handle graceful cancellation of the functions
+	f.shutdownCtx, f.shutdownCancel = context.WithCancel(ctx)
+
+	// Start awaiting on shutdown requests
+	f.startAwaitShutdown()
+
+	// Capture signals that could trigger shutdown
+	f.startSignalHandling()
+
+	// Start the functions
+	for _, fn := range myFuncs {
+		if err := f.addFn(fn); err != nil {
+			break // Shutdown has already begun
+		}
+	}
+
+	// Catch StartableFunctions that all exited, without triggering shutdown, before launch completed
+	f.started.Store(true)
+	f.shutdownIfIdle()
+
+	r := &Runtime{
+		f:    f,
+		done: make(chan struct{}),
+	}
+
+	go func() {
+		defer close(r.done)
+
+		r.report = f.awaitExit()
+		if r.report.Failed() {
+			r.err = r.report
+		}
+	}()
+
+	return r, nil
+}
+
+// Done returns a chan that is closed once shutdown has completed
+func (r *Runtime) Done() <-chan struct{} {
+	return r.done
+}
+
+// Wait blocks until shutdown has completed, returning the same error as Err
+func (r *Runtime) Wait() error {
+	<-r.done
+	return r.err
+}
+
+// Err returns nil until shutdown has completed, after which it returns an *ExitReport
+// if any StartableFunction failed or did not exit within the Timeout, otherwise nil
+func (r *Runtime) Err() error {
+	select {
+	case <-r.done:
+		return r.err
+	default:
+		return nil
+	}
+}
+
+// Report returns nil until shutdown has completed, after which it returns the ExitReport
+// describing how the StartableFunctions exited, regardless of whether any of them failed
+func (r *Runtime) Report() *ExitReport {
+	select {
+	case <-r.done:
+		return r.report
+	default:
+		return nil
+	}
+}
+
+// Shutdown requests that all StartableFunctions exit, and waits for shutdown to complete.
+// If the context is Done before shutdown completes, then its error is returned, otherwise
+// the result is the same as Err.  Shutdown can be called more than once.
+func (r *Runtime) Shutdown(ctx context.Context) error {
+	r.f.logger("shutdown requested", "shutdown_requested")
+	r.f.shutdown(FunctionExit{Reason: ExitCancelled})
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-r.done:
+		return r.err
+	}
+}
+
+// Add launches a further StartableFunction, which from then on is managed in the same way as those
+// provided to Start, including registration with the DiscoveryService.  Its Name must not be in use
+// by any other running StartableFunction, and it may only depend on running StartableFunctions.  An error is returned if the FunctionDeclaration is invalid,
+// or if shutdown has begun.  If Replicas is set, then each of the replicas is launched.
+func (r *Runtime) Add(fn FunctionDeclaration) error {
+	fn = fn.withDefaults()
+	if err := fn.validate(map[string]bool{}); err != nil {
+		return err
+	}
+	if fn.Replicas > 0 {
+		return r.f.addPool(fn)
+	}
+	return r.f.addFn(fn)
+}
+
+// Scale changes the number of running replicas of the named FunctionDeclaration, which must have been
+// declared with Replicas.  Further replicas are launched using the lowest unused indices, whilst surplus
+// replicas are stopped, highest index first, in the same way as Stop.  Scaling to zero stops all of the
+// replicas, without triggering shutdown.
+func (r *Runtime) Scale(name string, replicas int) error {
+	return r.f.scale(name, replicas)
+}
+
+// Stop retires the named StartableFunction by cancelling its context, without triggering shutdown
+// of the others, waiting up to its ShutdownTimeout, or the Timeout, for it to exit.  Once it has exited, its registration
+// with the DiscoveryService is removed, and its name can be reused by Add.  Should it not exit in time, then
+// ErrShutdownTimeout is returned, and its name can only be reused once it eventually exits.  Stop never triggers
+// shutdown, even if no other StartableFunctions remain running, so Shutdown must then be called.
+func (r *Runtime) Stop(name string) error {
+	return r.f.stopFn(name)
+}
+
+// DumpStacks writes the stacks of all goroutines to w, each labelled with the name of
+// its StartableFunction, if any, in the format of the pprof goroutine profile with debug=1
+func (r *Runtime) DumpStacks(w io.Writer) error {
+	return writeStacks(w)
+}
@@ -0,0 +1,288 @@
+package startup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func ExampleStart() {
+
+	worker := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		defer fmt.Printf("%s exited\n", opts.Self)
+		<-ctx.Done()
+	}
+
+	r, err := Start(context.Background(), []FunctionDeclaration{
+		{Name: "Worker", Func: worker},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	// Do other work, then request shutdown
+	if err := r.Shutdown(context.Background()); err != nil {
+		panic(err)
+	}
+
+	// Output:
+	// Worker exited
+}
+
+func TestStart(t *testing.T) {
+
+	_, err := Start(context.Background(), nil)
+
+	if err != ErrMissingStartableFunctions {
+		t.Fatalf("Expected error: ErrMissingStartableFunctions, got: %v", err)
+	}
+}
+
+func TestRuntime_Done(t *testing.T) {
+
+	release := make(chan struct{})
+
+	fn := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		<-release
+	}
+
+	r, err := Start(context.Background(), []FunctionDeclaration{
+		{Name: "Foo", Func: fn},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case <-r.Done():
+		t.Fatal("expected Start to return before the function exits")
+	default:
+	}
+	if r.Err() != nil || r.Report() != nil {
+		t.Fatal("expected no error or report before shutdown completes")
+	}
+
+	close(release)
+
+	if err := r.Wait(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report := r.Report(); report == nil || report.Trigger.Name != "Foo" || report.Trigger.Reason != ExitReturned {
+		t.Fatalf("expected report with Foo returning, got: %v", report)
+	}
+}
+
+func TestRuntime_Shutdown_Timeout(t *testing.T) {
+
+	release := make(chan struct{})
+	defer close(release)
+
+	stubborn := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		<-release
+	}
+
+	r, err := Start(context.Background(), []FunctionDeclaration{
+		{Name: "Stubborn", Func: stubborn},
+	}, WithTimeout(time.Second))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := r.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Expected error: context.DeadlineExceeded, got: %v", err)
+	}
+}
+
+func TestRuntime_AddStop(t *testing.T) {
+
+	dsCh := make(chan DiscoveryService, 1)
+
+	host := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		dsCh <- opts.DiscoveryService
+		<-ctx.Done()
+	}
+	tenant := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		<-ctx.Done()
+	}
+
+	r, err := Start(context.Background(), []FunctionDeclaration{
+		{Name: "Host", Func: host},
+	}, WithTimeout(time.Second))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer r.Shutdown(context.Background())
+
+	if err := r.Add(FunctionDeclaration{Name: "Tenant", Func: tenant, RegisterWithDiscoveryService: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.Add(FunctionDeclaration{Name: "Tenant", Func: tenant}); err != ErrNameAlreadyExists {
+		t.Fatalf("Expected error: ErrNameAlreadyExists, got: %v", err)
+	}
+
+	ds := <-dsCh
+
+	// Allow the registration to complete
+	deadline := time.Now().Add(time.Second)
+	for func() bool { _, err := ds.Find("Tenant"); return err != nil }() {
+		if time.Now().After(deadline) {
+			t.Fatal("expected Tenant to be registered")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := r.Stop("Tenant"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := ds.Find("Tenant"); err != ErrIDNotFound {
+		t.Fatalf("Expected error: ErrIDNotFound, got: %v", err)
+	}
+	if err := r.Stop("Tenant"); err != ErrFunctionNotFound {
+		t.Fatalf("Expected error: ErrFunctionNotFound, got: %v", err)
+	}
+
+	select {
+	case <-r.Done():
+		t.Fatal("expected Stop not to trigger shutdown")
+	default:
+	}
+
+	// Name can now be reused
+	if err := r.Add(FunctionDeclaration{Name: "Tenant", Func: tenant, RegisterWithDiscoveryService: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := r.Shutdown(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.Add(FunctionDeclaration{Func: tenant}); err != ErrShutdownInProgress {
+		t.Fatalf("Expected error: ErrShutdownInProgress, got: %v", err)
+	}
+}
+
+func TestRuntime_StopTimeout(t *testing.T) {
+
+	release := make(chan struct{})
+
+	host := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		<-ctx.Done()
+	}
+	slow := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		<-ctx.Done()
+		<-release
+	}
+
+	r, err := Start(context.Background(), []FunctionDeclaration{
+		{Name: "Host", Func: host},
+	}, WithTimeout(time.Second))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer r.Shutdown(context.Background())
+
+	if err := r.Add(FunctionDeclaration{Name: "Slow", Func: slow, ShutdownTimeout: 10 * time.Millisecond}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.Stop("Slow"); !errors.Is(err, ErrShutdownTimeout) {
+		t.Fatalf("Expected error: ErrShutdownTimeout, got: %v", err)
+	}
+	if err := r.Add(FunctionDeclaration{Name: "Slow", Func: host}); err != ErrNameAlreadyExists {
+		t.Fatalf("Expected error: ErrNameAlreadyExists, got: %v", err)
+	}
+
+	// Once it eventually exits, the name can be reused
+	close(release)
+
+	deadline := time.Now().Add(time.Second)
+	for r.Add(FunctionDeclaration{Name: "Slow", Func: host}) != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("expected Slow to be forgotten once it exited")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRuntime_AddRegistrationFailed(t *testing.T) {
+
+	dsCh := make(chan DiscoveryService, 1)
+
+	host := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		dsCh <- opts.DiscoveryService
+		<-ctx.Done()
+	}
+	fn := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		<-ctx.Done()
+	}
+
+	r, err := Start(context.Background(), []FunctionDeclaration{
+		{Name: "Host", Func: host},
+	}, WithTimeout(time.Second))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer r.Shutdown(context.Background())
+
+	// The ID is taken, so registration fails without the exit of the StartableFunction triggering shutdown
+	if _, err := CreateAndRegisterID(<-dsCh, "Dup", time.Minute, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	added := make(chan error, 1)
+	go func() {
+		added <- r.Add(FunctionDeclaration{Name: "Dup", Func: fn, RegisterWithDiscoveryService: true, ExitBehaviour: NeverShutdown})
+	}()
+
+	select {
+	case err := <-added:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected Add to return once registration failed")
+	}
+
+	select {
+	case <-r.Done():
+		t.Fatal("expected the failed registration not to trigger shutdown")
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestRuntime_AddExited(t *testing.T) {
+
+	host := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		<-ctx.Done()
+	}
+	once := func(ctx context.Context, opts *FunctionOptions, args ...any) {}
+
+	r, err := Start(context.Background(), []FunctionDeclaration{
+		{Name: "Host", Func: host},
+		{Name: "Once", Func: once, ExitBehaviour: NeverShutdown},
+	}, WithTimeout(time.Second))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer r.Shutdown(context.Background())
+
+	// Only running StartableFunctions reserve their names
+	deadline := time.Now().Add(time.Second)
+	for {
+		err := r.Add(FunctionDeclaration{Name: "Once", Func: host})
+		if err == nil {
+			break
+		}
+		if err != ErrNameAlreadyExists || time.Now().After(deadline) {
+			t.Fatalf("expected the name of the exited Once to be reused, got: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := r.Add(FunctionDeclaration{Name: "Once", Func: host}); err != ErrNameAlreadyExists {
+		t.Fatalf("Expected error: ErrNameAlreadyExists, got: %v", err)
+	}
+}
@@ -0,0 +1,249 @@
+package startup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+)
+
+// OverlapPolicy determines what happens when a ScheduledFunction is due to run while a previous run is in progress
+type OverlapPolicy int
+
+const (
+	// OverlapSkip skips the run (default)
+	OverlapSkip OverlapPolicy = iota
+	// OverlapQueue starts the run once the run in progress completes.  At most one run is queued,
+	// so further runs are skipped until the queued run starts.
+	OverlapQueue
+	// OverlapAllow starts the run regardless, so that runs may execute concurrently
+	OverlapAllow
+)
+
+// ErrInvalidSchedule is raised if a ScheduledFunction does not have exactly one of Interval or Cron,
+// if it has no Job, or if its other settings are invalid
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// ScheduledRun describes a single run of a ScheduledFunction
+type ScheduledRun struct {
+	// Scheduled is the time the run was due, before any jitter is applied
+	Scheduled time.Time
+	// Started is the time the run started, which is zero if the run was skipped
+	Started time.Time
+	// Finished is the time the run completed, which is zero if the run was skipped
+	Finished time.Time
+	// Skipped is true if the run did not take place due to the OverlapPolicy
+	Skipped bool
+	// Err is the error returned by the run, or a *PanicError if the run panicked
+	Err error
+}
+
+// ScheduledFunction runs a Job repeatedly, either at a fixed Interval or according to a Cron expression.
+// Its Run method is an ErrStartableFunction, so a ScheduledFunction is declared (using ErrFunc)
+// alongside other StartableFunctions, participating in startup and shutdown in the same way.
+//
+// Each run of the Job is given its own context, which is cancelled once the run completes or the
+// ScheduledFunction is shut down, and is recovered should it panic.  Shutdown waits for any runs in progress.
+type ScheduledFunction struct {
+	// Interval is the period between runs.  Exactly one of Interval or Cron must be set
+	Interval time.Duration
+	// Cron is a standard five field cron expression (minute, hour, day of month, month and day of week),
+	// evaluated in local time, or one of the descriptors such as @hourly or @daily.
+	// Exactly one of Interval or Cron must be set
+	Cron string
+	// Job is executed for each run, receiving the FunctionOptions and Args of the ScheduledFunction
+	Job ErrStartableFunction
+	// Overlap determines what happens when a run is due while a previous run is in progress (default is OverlapSkip)
+	Overlap OverlapPolicy
+	// Jitter delays each run by a random duration of up to Jitter, so that runs are spread out
+	Jitter time.Duration
+	// RunTimeout, if set, limits the duration of each run by cancelling its context
+	RunTimeout time.Duration
+	// RunOnStart, if true, runs the Job as soon as the ScheduledFunction starts, as well as when scheduled
+	RunOnStart bool
+	// StopOnError, if true, returns the error of a failed run, so that the failure escalates in the
+	// same way as for any other ErrStartableFunction.  Otherwise failures are logged and recorded in the history.
+	StopOnError bool
+	// HistorySize is the number of runs retained in the history (default is 10)
+	HistorySize int
+
+	lck     sync.Mutex
+	history []ScheduledRun
+}
+
+// History returns the most recent runs of the ScheduledFunction, including any that were skipped, oldest first
+func (s *ScheduledFunction) History() []ScheduledRun {
+	s.lck.Lock()
+	defer s.lck.Unlock()
+
+	return slices.Clone(s.history)
+}
+
+// record adds the run to the history, discarding the oldest runs beyond the HistorySize
+func (s *ScheduledFunction) record(r ScheduledRun) {
+	s.lck.Lock()
+	defer s.lck.Unlock()
+
+	size := s.HistorySize
+	if size <= 0 {
+		size = 10
+	}
+
+	s.history = append(s.history, r)
+	if len(s.history) > size {
+		s.history = slices.Delete(s.history, 0, len(s.history)-size)
+	}
+}
+
+// validate checks the settings of the ScheduledFunction, returning a func providing the next
+// scheduled time after the time supplied
+func (s *ScheduledFunction) validate() (func(time.Time) time.Time, error) {
+	if s.Job == nil {
+		return nil, fmt.Errorf("%w: Job must not be nil", ErrInvalidSchedule)
+	}
+	if s.Overlap < OverlapSkip || s.Overlap > OverlapAllow {
+		return nil, fmt.Errorf("%w: unknown OverlapPolicy", ErrInvalidSchedule)
+	}
+	if s.Jitter < 0 || s.RunTimeout < 0 {
+		return nil, fmt.Errorf("%w: Jitter and RunTimeout must not be negative", ErrInvalidSchedule)
+	}
+
+	switch {
+	case s.Interval > 0 && len(s.Cron) == 0:
+		return func(t time.Time) time.Time { return t.Add(s.Interval) }, nil
+	case s.Interval == 0 && len(s.Cron) > 0:
+		c, err := parseCron(s.Cron)
+		if err != nil {
+			return nil, err
+		}
+		return c.next, nil
+	default:
+		return nil, fmt.Errorf("%w: exactly one of a positive Interval or Cron must be set", ErrInvalidSchedule)
+	}
+}
+
+// Run is an ErrStartableFunction that executes the Job according to the schedule until its context is Done,
+// or until a run fails if StopOnError is set.  Args are passed to each run of the Job.
+func (s *ScheduledFunction) Run(ctx context.Context, opts *FunctionOptions, args ...any) error {
+	if opts == nil {
+		opts = &FunctionOptions{}
+	}
+
+	next, err := s.validate()
+	if err != nil {
+		return err
+	}
+
+	fn := FunctionDeclaration{Name: opts.Self, ErrFunc: s.Job, Args: args}
+
+	exits := make(chan ScheduledRun)
+	stopped := make(chan struct{})
+
+	var wg sync.WaitGroup
+	defer wg.Wait()      // Shutdown waits for runs in progress...
+	defer close(stopped) // ...which no longer report their exits...
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // ...once they are cancelled
+
+	running := 0
+	queued := false
+	var queuedAt time.Time
+
+	start := func(scheduled time.Time) {
+		running++
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			runCtx, runCancel := ctx, context.CancelFunc(func() {})
+			if s.RunTimeout > 0 {
+				runCtx, runCancel = context.WithTimeout(ctx, s.RunTimeout)
+			}
+			defer runCancel()
+
+			r := ScheduledRun{Scheduled: scheduled, Started: time.Now()}
+			r.Err = callFunction(runCtx, &fn, *opts)
+			r.Finished = time.Now()
+
+			select {
+			case exits <- r:
+			case <-stopped:
+				s.record(r)
+			}
+		}()
+	}
+
+	skip := func(scheduled time.Time) {
+		s.record(ScheduledRun{Scheduled: scheduled, Skipped: true})
+	}
+
+	// Runs are due with respect to the schedule, however any that are missed, such as while
+	// the process is suspended, are not made up
+	last := time.Now()
+	if s.RunOnStart {
+		start(last)
+	}
+
+	for {
+		scheduled := next(last)
+		for !scheduled.IsZero() && scheduled.Before(time.Now()) {
+			scheduled = next(scheduled)
+		}
+		if scheduled.IsZero() {
+			return fmt.Errorf("%w: %q is never due", ErrInvalidSchedule, s.Cron)
+		}
+
+		delay := time.Until(scheduled)
+		if s.Jitter > 0 {
+			delay += rand.N(s.Jitter)
+		}
+		timer := time.NewTimer(delay)
+
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil
+			case r := <-exits:
+				running--
+				s.record(r)
+
+				if r.Err != nil {
+					if s.StopOnError {
+						timer.Stop()
+						return fmt.Errorf("scheduled run of %s failed: %w", opts.Self, r.Err)
+					}
+					if opts.Logger != nil {
+						opts.Logger.Error(fmt.Sprintf("scheduled run of %s failed: %v", opts.Self, r.Err),
+							LogKeyEvent, "scheduled_run_failed", LogKeyError, r.Err)
+					}
+				}
+
+				if queued && running == 0 {
+					queued = false
+					start(queuedAt)
+				}
+			case <-timer.C:
+				break wait
+			}
+		}
+
+		last = scheduled
+
+		switch {
+		case running == 0 || s.Overlap == OverlapAllow:
+			start(scheduled)
+		case s.Overlap == OverlapQueue && !queued:
+			queued = true
+			queuedAt = scheduled
+		default:
+			skip(scheduled)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package startup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func ExampleScheduledFunction() {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var runs atomic.Int32
+
+	cleanup := &ScheduledFunction{
+		Interval: 20 * time.Millisecond,
+		Job: func(ctx context.Context, opts *FunctionOptions, args ...any) error {
+			if runs.Add(1) == 3 {
+				cancel()
+			}
+			return nil
+		},
+	}
+
+	StartNamedFunctions(ctx, []FunctionDeclaration{
+		{Name: "Cleanup", ErrFunc: cleanup.Run},
+	})
+
+	fmt.Printf("Runs: %d, History: %d\n", runs.Load(), len(cleanup.History()))
+	// Output:
+	// Runs: 3, History: 3
+}
+
+// scheduledRuns runs the ScheduledFunction for the duration, returning its history
+func scheduledRuns(t *testing.T, s *ScheduledFunction, d time.Duration) []ScheduledRun {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+
+	StartNamedFunctions(ctx, []FunctionDeclaration{
+		{Name: "Scheduled", ErrFunc: s.Run},
+	})
+
+	return s.History()
+}
+
+func TestScheduledFunction_OverlapSkip(t *testing.T) {
+
+	s := &ScheduledFunction{
+		Interval:   10 * time.Millisecond,
+		RunOnStart: true,
+		Job: func(ctx context.Context, opts *FunctionOptions, args ...any) error {
+			time.Sleep(25 * time.Millisecond)
+			return nil
+		},
+	}
+
+	var runs, skipped int
+	for _, r := range scheduledRuns(t, s, 55*time.Millisecond) {
+		if r.Skipped {
+			skipped++
+		} else {
+			runs++
+		}
+	}
+
+	if runs < 2 || skipped < 2 {
+		t.Fatalf("expected runs and skipped runs, got: %d runs and %d skipped", runs, skipped)
+	}
+}
+
+func TestScheduledFunction_OverlapQueue(t *testing.T) {
+
+	var running, maxRunning atomic.Int32
+
+	s := &ScheduledFunction{
+		Interval:   10 * time.Millisecond,
+		Overlap:    OverlapQueue,
+		RunOnStart: true,
+		Job: func(ctx context.Context, opts *FunctionOptions, args ...any) error {
+			n := running.Add(1)
+			defer running.Add(-1)
+			if n > maxRunning.Load() {
+				maxRunning.Store(n)
+			}
+			time.Sleep(15 * time.Millisecond)
+			return nil
+		},
+	}
+
+	var runs int
+	for _, r := range scheduledRuns(t, s, 100*time.Millisecond) {
+		if !r.Skipped {
+			runs++
+		}
+		if !r.Skipped && r.Started.Sub(r.Scheduled) < 0 {
+			t.Fatalf("expected run to start after it was scheduled, got: %v", r)
+		}
+	}
+
+	if n := maxRunning.Load(); n != 1 {
+		t.Fatalf("expected runs not to overlap, got: %d concurrent runs", n)
+	}
+	if runs < 4 {
+		t.Fatalf("expected queued runs to start back to back, got: %d runs", runs)
+	}
+}
+
+func TestScheduledFunction_OverlapAllow(t *testing.T) {
+
+	var running, maxRunning atomic.Int32
+
+	s := &ScheduledFunction{
+		Interval: 5 * time.Millisecond,
+		Overlap:  OverlapAllow,
+		Job: func(ctx context.Context, opts *FunctionOptions, args ...any) error {
+			n := running.Add(1)
+			defer running.Add(-1)
+			if n > maxRunning.Load() {
+				maxRunning.Store(n)
+			}
+			<-ctx.Done()
+			return nil
+		},
+		RunTimeout: 22 * time.Millisecond,
+	}
+
+	history := scheduledRuns(t, s, 50*time.Millisecond)
+
+	if n := maxRunning.Load(); n < 2 {
+		t.Fatalf("expected concurrent runs, got: %d", n)
+	}
+	for _, r := range history {
+		if r.Skipped {
+			t.Fatalf("expected no skipped runs, got: %v", r)
+		}
+	}
+}
+
+func TestScheduledFunction_StopOnError(t *testing.T) {
+
+	errFailed := errors.New("failed")
+	var runs atomic.Int32
+
+	s := &ScheduledFunction{
+		Interval:    5 * time.Millisecond,
+		StopOnError: true,
+		Job: func(ctx context.Context, opts *FunctionOptions, args ...any) error {
+			if runs.Add(1) == 2 {
+				return errFailed
+			}
+			return nil
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	rt, err := Start(ctx, []FunctionDeclaration{
+		{Name: "Scheduled", ErrFunc: s.Run},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := rt.Wait(); !errors.Is(err, errFailed) {
+		t.Fatalf("expected shutdown to be triggered by the failed run, got: %v", err)
+	}
+	if n := runs.Load(); n != 2 {
+		t.Fatalf("expected 2 runs, got: %d", n)
+	}
+}
+
+func TestScheduledFunction_Panic(t *testing.T) {
+
+	s := &ScheduledFunction{
+		Interval:    5 * time.Millisecond,
+		HistorySize: 3,
+		Job: func(ctx context.Context, opts *FunctionOptions, args ...any) error {
+			panic("Boom!")
+		},
+	}
+
+	history := scheduledRuns(t, s, 50*time.Millisecond)
+
+	if len(history) != 3 {
+		t.Fatalf("expected history to be limited to 3 runs, got: %d", len(history))
+	}
+	var perr *PanicError
+	if !errors.As(history[0].Err, &perr) {
+		t.Fatalf("expected panic to be recovered, got: %v", history[0].Err)
+	}
+}
+
+func TestScheduledFunction_Shutdown(t *testing.T) {
+
+	started := make(chan struct{})
+	var cancelled atomic.Bool
+
+	s := &ScheduledFunction{
+		Interval:   time.Hour,
+		RunOnStart: true,
+		Job: func(ctx context.Context, opts *FunctionOptions, args ...any) error {
+			close(started)
+			<-ctx.Done()
+			time.Sleep(10 * time.Millisecond)
+			cancelled.Store(true)
+			return nil
+		},
+	}
+	other := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		<-started
+	}
+
+	StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{Name: "Scheduled", ErrFunc: s.Run},
+		{Name: "Other", Func: other},
+	})
+
+	if !cancelled.Load() {
+		t.Fatal("expected shutdown to wait for the run in progress")
+	}
+	if h := s.History(); len(h) != 1 || h[0].Finished.IsZero() {
+		t.Fatalf("expected the run in progress to be recorded, got: %v", h)
+	}
+}
+
+func TestScheduledFunction_Invalid(t *testing.T) {
+
+	job := func(ctx context.Context, opts *FunctionOptions, args ...any) error { return nil }
+
+	for _, s := range []*ScheduledFunction{
+		{Job: job},
+		{Job: job, Interval: time.Second, Cron: "@daily"},
+		{Interval: time.Second},
+		{Job: job, Interval: time.Second, Jitter: -1},
+		{Job: job, Interval: time.Second, Overlap: 3},
+	} {
+		if err := s.Run(context.Background(), nil); !errors.Is(err, ErrInvalidSchedule) {
+			t.Fatalf("Expected error: ErrInvalidSchedule, got: %v", err)
+		}
+	}
+
+	s := &ScheduledFunction{Job: job, Cron: "61 * * * *"}
+	if err := s.Run(context.Background(), nil); !errors.Is(err, ErrInvalidCron) {
+		t.Fatalf("Expected error: ErrInvalidCron, got: %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package startup
+
+import (
+	"slices"
+	"time"
+)
+
+// shutdownTiers groups the StartableFunctions into the tiers in which they are shut down.
+// A StartableFunction is always shut down in a later tier than any StartableFunction that
+// depends on it, and no earlier than its ShutdownPriority.  Within each tier, the order in
+// which the StartableFunctions were launched is preserved.
+func shutdownTiers(fns []*fnEntry) [][]*fnEntry {
+	dependents := make(map[*fnEntry][]*fnEntry, len(fns))
+	for _, e := range fns {
+		for _, d := range e.deps {
+			dependents[d] = append(dependents[d], e)
+		}
+	}
+
+	ranks := make(map[*fnEntry]int, len(fns))
+
+	// Dependencies are acyclic, so the recursion terminates
+	var rank func(e *fnEntry) int
+	rank = func(e *fnEntry) int {
+		if r, ok := ranks[e]; ok {
+			return r
+		}
+		r := e.priority
+		for _, d := range dependents[e] {
+			r = max(r, rank(d)+1)
+		}
+		ranks[e] = r
+		return r
+	}
+
+	byRank := map[int][]*fnEntry{}
+	for _, e := range fns {
+		r := rank(e)
+		byRank[r] = append(byRank[r], e)
+	}
+
+	var order []int
+	for r := range byRank {
+		order = append(order, r)
+	}
+	slices.Sort(order)
+
+	tiers := make([][]*fnEntry, 0, len(order))
+	for _, r := range order {
+		tiers = append(tiers, byRank[r])
+	}
+	return tiers
+}
+
+// awaitTier waits for all of the StartableFunctions in the tier to exit, each for up to its
+// own ShutdownTimeout, or the specified duration if it has none, returning those that did not exit
+func awaitTier(tier []*fnEntry, d time.Duration) []*fnEntry {
+	begin := time.Now()
+
+	var overran []*fnEntry
+	for _, e := range tier {
+		timeout := time.NewTimer(time.Until(begin.Add(e.shutdownTimeout(d))))
+		select {
+		case <-e.done:
+		case <-timeout.C:
+			overran = append(overran, e)
+		}
+		timeout.Stop()
+	}
+	return overran
+}
+
+// shutdownTimeout returns the ShutdownTimeout of the StartableFunction, or d if it has none
+func (e *fnEntry) shutdownTimeout(d time.Duration) time.Duration {
+	if e.timeout > 0 {
+		return e.timeout
+	}
+	return d
+}
+
+// entryNames returns the names of the StartableFunctions
+func entryNames(fns []*fnEntry) []string {
+	names := make([]string, 0, len(fns))
+	for _, e := range fns {
+		names = append(names, e.name)
+	}
+	return names
+}
@@ -0,0 +1,204 @@
+package startup
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Example_phasedShutdown() {
+
+	database := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		opts.Ready()
+		<-ctx.Done()
+		fmt.Println("database closed")
+	}
+
+	api := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		<-ctx.Done()
+
+		// Emulate completing in-flight requests, which still need the database
+		<-time.After(20 * time.Millisecond)
+		fmt.Println("api drained")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	StartNamedFunctions(ctx, []FunctionDeclaration{
+		{Name: "Database", Func: database},
+		{Name: "API", Func: api, DependsOn: []string{"Database"}},
+	})
+
+	// Output:
+	// api drained
+	// database closed
+}
+
+func TestShutdownTiers(t *testing.T) {
+
+	a := &fnEntry{name: "A"}
+	b := &fnEntry{name: "B", deps: []*fnEntry{a}}
+	c := &fnEntry{name: "C", deps: []*fnEntry{b}}
+	d := &fnEntry{name: "D", priority: 1}
+	e := &fnEntry{name: "E"}
+
+	var got [][]string
+	for _, tier := range shutdownTiers([]*fnEntry{a, b, c, d, e}) {
+		got = append(got, entryNames(tier))
+	}
+
+	expected := [][]string{{"C", "E"}, {"B", "D"}, {"A"}}
+	if !slices.EqualFunc(got, expected, slices.Equal) {
+		t.Fatalf("expected tiers %v, got: %v", expected, got)
+	}
+}
+
+func TestShutdownPriority(t *testing.T) {
+
+	var lck sync.Mutex
+	var order []string
+
+	fn := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		<-ctx.Done()
+		lck.Lock()
+		defer lck.Unlock()
+		order = append(order, opts.Self)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	StartNamedFunctions(ctx, []FunctionDeclaration{
+		{Name: "Late", Func: fn, ShutdownPriority: 2},
+		{Name: "Middle", Func: fn, ShutdownPriority: 1},
+		{Name: "Early", Func: fn},
+	})
+
+	if !slices.Equal(order, []string{"Early", "Middle", "Late"}) {
+		t.Fatalf("unexpected shutdown order: %v", order)
+	}
+}
+
+func TestShutdownTiers_Timeout(t *testing.T) {
+
+	release := make(chan struct{})
+	defer close(release)
+
+	var exited bool
+
+	stubborn := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		<-release
+	}
+	dependency := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		opts.Ready()
+		<-ctx.Done()
+		exited = true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := StartNamedFunctions(ctx, []FunctionDeclaration{
+		{Name: "Dependency", Func: dependency},
+		{Name: "Stubborn", Func: stubborn, DependsOn: []string{"Dependency"}},
+	}, WithTimeout(40*time.Millisecond))
+
+	report, ok := err.(*ExitReport)
+	if !ok {
+		t.Fatalf("expected *ExitReport, got: %v", err)
+	}
+	if !slices.Equal(report.Abandoned, []string{"Stubborn"}) {
+		t.Fatalf("expected Stubborn to be abandoned, got: %v", report.Abandoned)
+	}
+	if !exited {
+		t.Fatal("expected the next tier to be shut down once the tier timed out")
+	}
+}
+
+func TestShutdownTimeout_PerFunction(t *testing.T) {
+
+	release := make(chan struct{})
+	defer close(release)
+
+	stubborn := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		<-release
+	}
+	slow := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		<-ctx.Done()
+		<-time.After(30 * time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	begin := time.Now()
+
+	err := StartNamedFunctions(ctx, []FunctionDeclaration{
+		{Name: "Stubborn", Func: stubborn, ShutdownTimeout: 10 * time.Millisecond},
+		{Name: "Slow", Func: slow},
+	}, WithTimeout(time.Second))
+
+	report, ok := err.(*ExitReport)
+	if !ok {
+		t.Fatalf("expected *ExitReport, got: %v", err)
+	}
+	if !slices.Equal(report.Abandoned, []string{"Stubborn"}) {
+		t.Fatalf("expected only Stubborn to be abandoned, got: %v", report.Abandoned)
+	}
+	if time.Since(begin) > 500*time.Millisecond {
+		t.Fatal("expected shutdown to complete once Slow exited")
+	}
+	if report.Stacks != nil {
+		t.Fatalf("expected no stacks unless requested, got: %v", report.Stacks)
+	}
+}
+
+// blockUntilReleased is a named func, so that it can be found in stack traces
+func blockUntilReleased(release chan struct{}) {
+	<-release
+}
+
+func TestWithAbandonedStacks(t *testing.T) {
+
+	release := make(chan struct{})
+	defer close(release)
+
+	stubborn := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		go blockUntilReleased(release) // Goroutines started by the StartableFunction are included
+		<-release
+	}
+	short := func(ctx context.Context, opts *FunctionOptions, args ...any) {}
+
+	err := StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{Name: "Stubborn", Func: stubborn, ShutdownTimeout: 10 * time.Millisecond},
+		{Name: "Short", Func: short},
+	}, WithAbandonedStacks())
+
+	report, ok := err.(*ExitReport)
+	if !ok {
+		t.Fatalf("expected *ExitReport, got: %v", err)
+	}
+	stack := report.Stacks["Stubborn"]
+	if !strings.Contains(stack, "TestWithAbandonedStacks.func1") || !strings.Contains(stack, "blockUntilReleased") {
+		t.Fatalf("expected stacks of Stubborn and its goroutine, got: %s", stack)
+	}
+	if strings.Contains(stack, "TestWithAbandonedStacks.func2") {
+		t.Fatalf("expected only the stacks of Stubborn, got: %s", stack)
+	}
+}
+
+func TestShutdownTimeout_Invalid(t *testing.T) {
+
+	err := StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{Func: func(ctx context.Context, opts *FunctionOptions, args ...any) {}, ShutdownTimeout: -time.Second},
+	})
+
+	if err != ErrInvalidTimeout {
+		t.Fatalf("Expected error: ErrInvalidTimeout, got: %v", err)
+	}
+}
@@ -0,0 +1,248 @@
+//go:build unix
+
+package startup
+
+import (
+	"context"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestWithReloadSignal(t *testing.T) {
+
+	reloaded := make(chan struct{})
+
+	fn := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-opts.Reload:
+				reloaded <- struct{}{}
+			}
+		}
+	}
+
+	r, err := Start(context.Background(), []FunctionDeclaration{
+		{Name: "Foo", Func: fn},
+	}, WithReloadSignal(syscall.SIGHUP))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for range 2 {
+		syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+
+		select {
+		case <-reloaded:
+		case <-time.After(time.Second):
+			t.Fatal("expected Foo to be notified of reload")
+		}
+	}
+
+	select {
+	case <-r.Done():
+		t.Fatal("expected reload not to trigger shutdown")
+	default:
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGTERM)
+
+	select {
+	case <-r.Done():
+	case <-time.After(time.Second):
+		t.Fatal("expected SIGTERM to trigger shutdown")
+	}
+
+	if report := r.Report(); report == nil || report.Trigger.Reason != ExitInterrupted {
+		t.Fatalf("expected shutdown to be triggered by interrupt, got: %v", report)
+	}
+}
+
+func TestWithReloadSignal_Supervisor(t *testing.T) {
+
+	reloaded := make(chan string, 2)
+
+	child := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		select {
+		case <-ctx.Done():
+		case <-opts.Reload:
+			reloaded <- opts.Self
+			<-ctx.Done()
+		}
+	}
+
+	s := &Supervisor{
+		Children: []FunctionDeclaration{
+			{Name: "A", Func: child},
+			{Name: "B", Func: child},
+		},
+	}
+
+	r, err := Start(context.Background(), []FunctionDeclaration{
+		{ErrFunc: s.Run},
+	}, WithReloadSignal(syscall.SIGHUP))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer r.Shutdown(context.Background())
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+
+	for range 2 {
+		select {
+		case <-reloaded:
+		case <-time.After(time.Second):
+			t.Fatal("expected each child to be notified of reload")
+		}
+	}
+}
+
+func TestWithReloadSignal_Invalid(t *testing.T) {
+
+	fn := func(ctx context.Context, opts *FunctionOptions, args ...any) {}
+
+	_, err := Start(context.Background(), []FunctionDeclaration{{Func: fn}}, WithReloadSignal(syscall.SIGTERM))
+
+	if err != ErrInvalidReloadSignal {
+		t.Fatalf("Expected error: ErrInvalidReloadSignal, got: %v", err)
+	}
+
+	// The order of the Options does not matter
+	_, err = Start(context.Background(), []FunctionDeclaration{{Func: fn}},
+		WithReloadSignal(syscall.SIGHUP), WithSignals(os.Interrupt, syscall.SIGHUP))
+
+	if err != ErrInvalidReloadSignal {
+		t.Fatalf("Expected error: ErrInvalidReloadSignal, got: %v", err)
+	}
+
+	_, err = Start(context.Background(), []FunctionDeclaration{{Func: fn}}, WithReloadSignal(nil))
+
+	if err != ErrInvalidReloadSignal {
+		t.Fatalf("Expected error: ErrInvalidReloadSignal, got: %v", err)
+	}
+}
+
+func TestWithSignals_ForcedExit(t *testing.T) {
+
+	exited := make(chan int, 1)
+
+	osExit = func(code int) { exited <- code }
+	defer func() { osExit = os.Exit }()
+
+	stopping := make(chan struct{})
+	release := make(chan struct{})
+
+	stubborn := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		<-ctx.Done()
+		close(stopping)
+		<-release
+	}
+
+	r, err := Start(context.Background(), []FunctionDeclaration{
+		{Name: "Stubborn", Func: stubborn},
+	}, WithSignals(syscall.SIGUSR1), WithTimeout(time.Second))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+	<-stopping
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+
+	select {
+	case code := <-exited:
+		if code != 1 {
+			t.Fatalf("expected exit code 1, got: %d", code)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected second signal to force exit")
+	}
+
+	close(release)
+	r.Wait()
+}
+
+func TestWithSignals_DuringShutdown(t *testing.T) {
+
+	exited := make(chan int, 1)
+
+	osExit = func(code int) { exited <- code }
+	defer func() { osExit = os.Exit }()
+
+	stopping := make(chan struct{})
+	release := make(chan struct{})
+
+	stubborn := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		<-ctx.Done()
+		close(stopping)
+		<-release
+	}
+	short := func(ctx context.Context, opts *FunctionOptions, args ...any) {}
+
+	r, err := Start(context.Background(), []FunctionDeclaration{
+		{Name: "Stubborn", Func: stubborn},
+		{Name: "Short", Func: short},
+	}, WithSignals(syscall.SIGUSR1), WithTimeout(time.Second))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Shutdown has been triggered by Short, so the first signal does not force exit
+	<-stopping
+	syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+
+	select {
+	case code := <-exited:
+		t.Fatalf("expected first signal not to force exit, got exit code: %d", code)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+
+	select {
+	case <-exited:
+	case <-time.After(time.Second):
+		t.Fatal("expected second signal to force exit")
+	}
+
+	close(release)
+	r.Wait()
+}
+
+func TestWithStackDump_SIGQUIT(t *testing.T) {
+
+	fn := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		<-ctx.Done()
+	}
+
+	var buf syncBuffer
+
+	r, err := Start(context.Background(), []FunctionDeclaration{
+		{Name: "Foo", Func: fn},
+	}, WithStackDump(&buf))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer r.Shutdown(context.Background())
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGQUIT)
+
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(buf.String(), `"startup_function":"Foo"`) {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected SIGQUIT to dump stacks, got: %s", buf.String())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	select {
+	case <-r.Done():
+		t.Fatal("expected SIGQUIT not to trigger shutdown")
+	default:
+	}
+}
@@ -0,0 +1,61 @@
+package startup
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"runtime/pprof"
+	"strings"
+)
+
+// WithStackDump requests that the stacks of all goroutines are dumped should shutdown time out,
+// or should SIGQUIT be received (which then no longer terminates the process).  Goroutines are
+// labelled with the name of their StartableFunction.  If w is nil, then the dump is logged.
+func WithStackDump(w io.Writer) OptionSetter {
+	return func(o *Options) error {
+		o.StackDump = true
+		o.StackDumpWriter = w
+		return nil
+	}
+}
+
+// writeStacks writes the stacks of all goroutines to w, in the format of the goroutine
+// profile with debug=1, which groups identical stacks and includes their pprof labels
+func writeStacks(w io.Writer) error {
+	return pprof.Lookup("goroutine").WriteTo(w, 1)
+}
+
+// dumpStacks writes the stacks of all goroutines to the StackDumpWriter, or the logger if there is none
+func (f *funcMgr) dumpStacks(reason string) {
+	var buf bytes.Buffer
+	writeStacks(&buf)
+
+	if f.o.StackDumpWriter != nil {
+		fmt.Fprintf(f.o.StackDumpWriter, "%s\n%s\n", reason, buf.String())
+		return
+	}
+	f.log.Error(fmt.Sprintf("%s\n%s", reason, buf.String()), LogKeyEvent, "stack_dump")
+}
+
+// goroutineStacks returns the stacks of the goroutines carrying the specified pprof label,
+// in the format of the goroutine profile with debug=1, which groups identical stacks
+func goroutineStacks(key, value string) string {
+	var buf bytes.Buffer
+	writeStacks(&buf)
+
+	label := fmt.Sprintf("%q:%q", key, value)
+
+	var stacks []string
+	// The first record is preceded by a header line
+	_, profile, _ := strings.Cut(buf.String(), "\n")
+
+	for _, record := range strings.Split(profile, "\n\n") {
+		for _, line := range strings.Split(record, "\n") {
+			if strings.HasPrefix(line, "# labels: ") && strings.Contains(line, label) {
+				stacks = append(stacks, strings.TrimSpace(record))
+				break
+			}
+		}
+	}
+	return strings.Join(stacks, "\n\n")
+}
@@ -0,0 +1,77 @@
+package startup
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer that is safe for concurrent use
+type syncBuffer struct {
+	lck sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.lck.Lock()
+	defer b.lck.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.lck.Lock()
+	defer b.lck.Unlock()
+	return b.buf.String()
+}
+
+func TestWithStackDump_Timeout(t *testing.T) {
+
+	release := make(chan struct{})
+	defer close(release)
+
+	stubborn := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		<-release
+	}
+	short := func(ctx context.Context, opts *FunctionOptions, args ...any) {}
+
+	var buf syncBuffer
+
+	StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{Name: "Stubborn", Func: stubborn},
+		{Name: "Short", Func: short},
+	}, WithStackDump(&buf), WithTimeout(10*time.Millisecond))
+
+	dump := buf.String()
+	if !strings.HasPrefix(dump, "shutdown timed out, abandoning [Stubborn]") {
+		t.Fatalf("expected dump to describe the timeout, got: %s", dump)
+	}
+	if !strings.Contains(dump, `"startup_function":"Stubborn"`) || !strings.Contains(dump, "TestWithStackDump_Timeout.func1") {
+		t.Fatalf("expected dump to include the labelled stack of Stubborn, got: %s", dump)
+	}
+}
+
+func TestRuntime_DumpStacks(t *testing.T) {
+
+	fn := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		<-ctx.Done()
+	}
+
+	r, err := Start(context.Background(), []FunctionDeclaration{
+		{Name: "Foo", Func: fn},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer r.Shutdown(context.Background())
+
+	var buf bytes.Buffer
+	if err := r.DumpStacks(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"startup_function":"Foo"`) {
+		t.Fatalf("expected dump to include the labelled stack of Foo, got: %s", buf.String())
+	}
+}
@@ -6,12 +6,18 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"os"
 	"os/signal"
 	"reflect"
 	"runtime"
+	"runtime/debug"
+	"slices"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 )
 
@@ -19,21 +25,81 @@ import (
 type FunctionOptions struct {
 	// Self returns the name of this StartableFunction
 	Self string
+	// Group is the name of the replicated FunctionDeclaration, if this StartableFunction is one of its replicas
+	Group string
 	// DiscoveryService is available only when using StartNamedFunctions
 	DiscoveryService DiscoveryService
 	// Identity is populated if the StartableFunction has been registered with the DiscoveryService
 	Identity Identity
+	// Reload receives a notification each time the reload signal is received (see WithReloadSignal).
+	// Notifications are not queued, so several signals received in quick succession may result in a
+	// single notification.  Reload is nil if no reload signal has been specified.
+	Reload <-chan struct{}
+	// Logger records structured activity for this StartableFunction, with its name (and Identity, if registered)
+	// as attributes.  Records are discarded if no logging has been requested.
+	Logger *slog.Logger
+	// Metrics allows the StartableFunction to record its own measurements alongside those of the runtime.
+	// Measurements are discarded if WithMetrics has not been specified.
+	Metrics Metrics
+	// ready is called when the StartableFunction declares itself ready
+	ready func()
+	// group tracks the goroutines started by the current run of the StartableFunction
+	group *taskGroup
+	// registrationTTL is the lease of registrations with the DiscoveryService, if any
+	registrationTTL time.Duration
+	// heartbeat is true if leases are renewed only by Heartbeat, rather than automatically
+	heartbeat bool
+}
+
+// Ready declares that the StartableFunction is ready, allowing any StartableFunctions that
+// depend on it to start.  Calling Ready more than once, or across restarts, has no further effect.
+func (o *FunctionOptions) Ready() {
+	if o.ready != nil {
+		o.ready()
+	}
+}
+
+// Heartbeat renews the lease of the registration of the StartableFunction with the DiscoveryService
+// (see WithRegistrationTTL), so that it remains discoverable whilst it is making progress.  Leases are renewed
+// automatically, unless WithHeartbeatRenewal has been specified, in which case a leased StartableFunction
+// must call Heartbeat more often than the TTL.
+// ErrIDNotFound is returned if the lease has already expired.  Heartbeat has no effect if the
+// StartableFunction is not registered, or its registration is not leased.
+func (o *FunctionOptions) Heartbeat() error {
+	if o.Identity == nil || o.DiscoveryService == nil {
+		return nil
+	}
+	return o.DiscoveryService.Renew(o.Identity.ID())
+}
+
+// Go starts fn in a new goroutine that is managed alongside the StartableFunction.  Should fn panic
+// or return an error, the context of the StartableFunction is cancelled and the failure is reported
+// as part of its exit, as if it had occurred in the StartableFunction itself.  The StartableFunction is
+// not considered to have exited until all such goroutines have returned; the context passed to fn is
+// cancelled once the StartableFunction returns, after which returning its error is not a failure.
+// ErrFunctionExited is returned if the StartableFunction has already exited.
+func (o *FunctionOptions) Go(fn func(context.Context) error) error {
+	if o.group == nil {
+		return ErrFunctionExited
+	}
+	return o.group.Go(fn)
 }
 
 // StartableFunction defines a func that can be provided to StartFunctions
 type StartableFunction func(context.Context, *FunctionOptions, ...any)
 
+// ErrStartableFunction defines a func that can report its failure by returning an error,
+// rather than by panicking.  Returning context.Canceled once its context is cancelled is not a failure.
+type ErrStartableFunction func(context.Context, *FunctionOptions, ...any) error
+
 // FunctionDeclaration provides details about each StartableFunction
 type FunctionDeclaration struct {
 	// Name must be unique, if specified
 	Name string
-	// Func is the StartableFunction to be started, and must not be nil
+	// Func is the StartableFunction to be started.  Exactly one of Func or ErrFunc must be set
 	Func StartableFunction
+	// ErrFunc is the ErrStartableFunction to be started.  Exactly one of Func or ErrFunc must be set
+	ErrFunc ErrStartableFunction
 	// Args will be passed to the StartableFunction as it is launched
 	Args []any
 	// RegisterWithDiscoveryService, if true, will attempt to register the StartableFunction with the
@@ -44,6 +110,37 @@ type FunctionDeclaration struct {
 	// Handler will be used to listen for and process incoming messages.
 	// If nil, the StartableFunction still has access to the DiscoveryService to initate listening manually.
 	Handler Handler
+	// RestartPolicy determines whether the StartableFunction is restarted in place when it exits,
+	// rather than triggering shutdown of all StartableFunctions (default is never to restart)
+	RestartPolicy RestartPolicy
+	// DependsOn lists the names of the StartableFunctions that must declare themselves ready,
+	// by calling FunctionOptions.Ready(), before this StartableFunction is started
+	DependsOn []string
+	// ShutdownPriority orders shutdown, with StartableFunctions of higher priority shut down later.
+	// Regardless of priority, a StartableFunction is always shut down after those that depend on it.
+	ShutdownPriority int
+	// ShutdownTimeout, if set, is the duration the StartableFunction is allowed to exit during shutdown,
+	// or when stopped, rather than its share of Options.Timeout
+	ShutdownTimeout time.Duration
+	// ExitBehaviour determines whether the exit of the StartableFunction triggers shutdown (default is
+	// ShutdownOnExit).  StartableFunctions that are expected to return, such as one-off initialisers,
+	// can use ShutdownOnFailure.  If no StartableFunctions remain running, shutdown is always triggered, unless
+	// the last of them was retired by Runtime.Stop or Runtime.Scale, which never trigger shutdown.
+	ExitBehaviour ExitBehaviour
+	// Replicas, if set, launches the specified number of instances of the StartableFunction, named
+	// Name-0 to Name-(Replicas-1), which are registered with the DiscoveryService as members of the group Name.
+	// Depending on Name depends on all of the replicas.  The number of replicas can be changed using Runtime.Scale
+	Replicas int
+	// Service, if set, registers the StartableFunction with the DiscoveryService as an instance of the named service,
+	// so that Connect can be given the service name rather than its Name.  Replicas are instances of the service
+	// Name, unless Service is set
+	Service string
+	// Metadata describes the StartableFunction when registered with the DiscoveryService, so that
+	// it can be found using DiscoveryService.Query.  Replicas share the same Metadata
+	Metadata Metadata
+
+	// group is the Name of the replicated FunctionDeclaration, for each of its replicas
+	group string
 }
 
 // createNameIfMissing ensures name is only set if it doesn't already exist
@@ -70,6 +167,17 @@ var ErrNameAlreadyExists = errors.New("function names must be unique")
 // ErrFuncMustNotBeNil is raised when no StartableFunction has been assigned to the FunctionDeclaration
 var ErrFuncMustNotBeNil = errors.New("function must not be nil")
 
+// ErrAmbiguousFunc is raised when both Func and ErrFunc have been assigned to the FunctionDeclaration
+var ErrAmbiguousFunc = errors.New("only one of Func or ErrFunc may be set")
+
+// withDefaults returns a copy of the FunctionDeclaration with a name assigned, if missing,
+// and the args slice populated
+func (f FunctionDeclaration) withDefaults() FunctionDeclaration {
+	f.Args = createArgsIfMissing(f.Args)
+	f.Name = createNameIfMissing(f.Name)
+	return f
+}
+
 func (f FunctionDeclaration) validate(m map[string]bool) error {
 	// Name must be unique
 	if _, ok := m[f.Name]; ok {
@@ -78,11 +186,37 @@ func (f FunctionDeclaration) validate(m map[string]bool) error {
 		m[f.Name] = true
 	}
 
-	if f.Func == nil {
+	if f.Func == nil && f.ErrFunc == nil {
 		return ErrFuncMustNotBeNil
 	}
+	if f.Func != nil && f.ErrFunc != nil {
+		return ErrAmbiguousFunc
+	}
+	if err := f.ExitBehaviour.validate(); err != nil {
+		return err
+	}
+	if f.ShutdownTimeout < 0 {
+		return ErrInvalidTimeout
+	}
+	if f.Replicas < 0 {
+		return ErrInvalidReplicas
+	}
 
-	return nil
+	return f.RestartPolicy.validate()
+}
+
+// PanicError is the error recorded when a StartableFunction exits due to an unhandled panic
+type PanicError struct {
+	// Func is the name of the StartableFunction, as known to the runtime
+	Func string
+	// Value is the value that was passed to panic()
+	Value any
+	// Stack is the stack trace of the goroutine that panicked
+	Stack []byte
+}
+
+func (p *PanicError) Error() string {
+	return fmt.Sprintf("caught unhandled panic in (%s): %v", p.Func, p.Value)
 }
 
 // Options allow the behaviour of StartFunctions to be modified
@@ -91,12 +225,40 @@ type Options struct {
 	Logger *log.Logger
 	// ReportPanicsOnly will limit logging to recording panics only, if set to true
 	ReportPanicsOnly bool
-	// Timeout specifies the duration to wait for StartableFunctions to gracefully exit
+	// SlogLogger specifies which slog.Logger should be used, taking precedence over Logger
+	SlogLogger *slog.Logger
+	// Timeout specifies the duration to wait for StartableFunctions to gracefully exit,
+	// which is divided equally between the tiers of a phased shutdown
 	Timeout time.Duration
 	// noDiscoveryService is not directly settable, set by StartFunctions
 	noDiscoveryService bool
-	// PauseDuration is the duration a routine will wait, to allow a goroutine it has started time to be to scheduled
+	// PauseDuration is no longer used, as goroutines are synchronised explicitly when started.
+	//
+	// Deprecated: PauseDuration has no effect.
 	PauseDuration time.Duration
+	// StartupTimeout specifies the duration a StartableFunction will wait for its dependencies to be ready
+	StartupTimeout time.Duration
+	// Signals are the signals that trigger shutdown.  Should a second of these signals be received
+	// before shutdown completes, the process exits immediately
+	Signals []os.Signal
+	// ReloadSignal, if not nil, is the signal that is delivered to StartableFunctions via FunctionOptions.Reload
+	ReloadSignal os.Signal
+	// Observers are notified of the lifecycle transitions of the StartableFunctions
+	Observers []LifecycleObserver
+	// Metrics, if not nil, records the metrics of the StartableFunctions and their Identities
+	Metrics Metrics
+	// AbandonedStacks, if true, captures the goroutine stacks of StartableFunctions that do not exit during shutdown
+	AbandonedStacks bool
+	// StackDump, if true, dumps the stacks of all goroutines should shutdown time out, or SIGQUIT be received
+	StackDump bool
+	// StackDumpWriter is where stack dumps are written, or if nil, they are logged
+	StackDumpWriter io.Writer
+	// RegistrationTTL, if set, is the lease of the registrations of StartableFunctions with the DiscoveryService,
+	// which are renewed automatically whilst StartableFunctions are running
+	RegistrationTTL time.Duration
+	// HeartbeatRenewal, if true, renews the leases of registrations only when StartableFunctions call
+	// FunctionOptions.Heartbeat, rather than automatically
+	HeartbeatRenewal bool
 }
 
 // OptionSetter type allows Options to be optionally set by caller to StartFunctions
@@ -129,6 +291,75 @@ func WithTimeout(d time.Duration) OptionSetter {
 	}
 }
 
+// WithStartupTimeout specifies the duration a StartableFunction will wait for the StartableFunctions
+// it depends on to be ready, after which it exits with ErrStartupTimeout.
+// Default is 30 seconds.
+func WithStartupTimeout(d time.Duration) OptionSetter {
+	return func(o *Options) error {
+		if d > 0 {
+			o.StartupTimeout = d
+			return nil
+		}
+		return ErrInvalidTimeout
+	}
+}
+
+// WithRegistrationTTL specifies that registrations of StartableFunctions with the DiscoveryService are leased
+// for the duration d.  Leases are renewed automatically whilst StartableFunctions are running, so that the
+// registration of a StartableFunction that has exited without being deregistered expires and can no longer be found.
+func WithRegistrationTTL(d time.Duration) OptionSetter {
+	return func(o *Options) error {
+		if d > 0 {
+			o.RegistrationTTL = d
+			return nil
+		}
+		return ErrInvalidTimeout
+	}
+}
+
+// WithHeartbeatRenewal specifies that the leases of registrations (see WithRegistrationTTL) are renewed only
+// when StartableFunctions call FunctionOptions.Heartbeat as they make progress, rather than automatically,
+// so that the registration of a StartableFunction that is stuck expires and can no longer be found.
+func WithHeartbeatRenewal() OptionSetter {
+	return func(o *Options) error {
+		o.HeartbeatRenewal = true
+		return nil
+	}
+}
+
+// WithSignals specifies the signals that trigger shutdown, replacing the default of os.Interrupt and
+// syscall.SIGTERM.  If no signals are specified, then no signals are captured.
+func WithSignals(sigs ...os.Signal) OptionSetter {
+	return func(o *Options) error {
+		o.Signals = append([]os.Signal{}, sigs...)
+		return nil
+	}
+}
+
+// WithAbandonedStacks captures the goroutine stacks of any StartableFunctions that do not exit during shutdown,
+// including the goroutines that they started, which are then logged and included in the ExitReport
+func WithAbandonedStacks() OptionSetter {
+	return func(o *Options) error {
+		o.AbandonedStacks = true
+		return nil
+	}
+}
+
+// ErrInvalidReloadSignal raised if WithReloadSignal() is called with nil, or with one of the shutdown signals
+var ErrInvalidReloadSignal = errors.New("reload signal must not be nil or a shutdown signal")
+
+// WithReloadSignal specifies a signal (typically syscall.SIGHUP) that is delivered to each StartableFunction
+// via FunctionOptions.Reload, rather than triggering shutdown
+func WithReloadSignal(sig os.Signal) OptionSetter {
+	return func(o *Options) error {
+		if sig == nil {
+			return ErrInvalidReloadSignal
+		}
+		o.ReloadSignal = sig
+		return nil
+	}
+}
+
 // withoutDiscoveryService specifies a DiscoveryService should NOT be created
 // This is specified when StartFunctions is used rather than StartNamedFunctions,
 // as the goroutines started by StartFunctions are anonymous, and hence no communication
@@ -140,23 +371,24 @@ func withoutDiscoveryService() OptionSetter {
 	}
 }
 
-// ErrInvalidPauseTimeout raised if WithPauseDuration is less than one millisecond
+// ErrInvalidPauseTimeout is no longer raised.
+//
+// Deprecated: WithPauseDuration no longer validates its duration.
 var ErrInvalidPauseTimeout = errors.New("pause duration must be greater than one millisecond")
 
-// WithPauseDuration specifies the pause for goroutine scheduling, must be greater than 1ms
+// WithPauseDuration previously specified the pause for goroutine scheduling.
+//
+// Deprecated: goroutines are now synchronised explicitly when started, so this has no effect.
 func WithPauseDuration(d time.Duration) OptionSetter {
 	return func(o *Options) error {
-		if d > 1*time.Millisecond {
-			o.PauseDuration = d
-			return nil
-		}
-		return ErrInvalidPauseTimeout
+		return nil
 	}
 }
 
 var defaultOptions = Options{
-	Timeout:       30 * time.Second,
-	PauseDuration: 1 * time.Millisecond,
+	Timeout:        30 * time.Second,
+	StartupTimeout: 30 * time.Second,
+	Signals:        []os.Signal{os.Interrupt, syscall.SIGTERM},
 }
 
 // ErrMissingStartableFunctions is raised if no StartableFunctions are provided to StartFunctions
@@ -167,7 +399,7 @@ var ErrMissingStartableFunctions = errors.New("at least one StartableFunction mu
 // Should one of the functions exit, whether expected or due to a panic, then the contexts
 // of the other functions will be completed, so they will be expected to detect this and
 // shutdown gracefully as well.
-// Standard interrupts (CTRL-C) are captured, and these will trigger a shutdown request to
+// Standard interrupts (CTRL-C) and SIGTERM are captured, and these will trigger a shutdown request to
 // all functions.
 func StartFunctions(ctx context.Context, funcs []StartableFunction, opts ...OptionSetter) error {
 	var dfs = []FunctionDeclaration{}
@@ -190,209 +422,646 @@ func StartFunctions(ctx context.Context, funcs []StartableFunction, opts ...Opti
 // Should one of the functions exit, whether expected or due to a panic, then the contexts
 // of the other functions will be completed, so they will be expected to detect this and
 // shutdown gracefully as well.
-// Standard interrupts (CTRL-C) are captured, and these will trigger a shutdown request to
+// Standard interrupts (CTRL-C) and SIGTERM are captured, and these will trigger a shutdown request to
 // all functions.
+// Once shutdown completes, an *ExitReport is returned as the error if any function panicked,
+// returned an error, or did not exit within the Timeout, describing which function exited first and why.
+// StartNamedFunctions blocks until shutdown completes; use Start to retain control whilst the
+// functions are running.
 func StartNamedFunctions(ctx context.Context, funcs []FunctionDeclaration, opts ...OptionSetter) error {
-	if len(funcs) == 0 {
-		return ErrMissingStartableFunctions
-	}
-
-	names := make(map[string]bool, len(funcs))
-
-	// Ensure name applied
-	var myFuncs []FunctionDeclaration
-	for _, fn := range funcs {
-		myFuncs = append(myFuncs, FunctionDeclaration{
-			Args:                         createArgsIfMissing(fn.Args),
-			Func:                         fn.Func,
-			Name:                         createNameIfMissing(fn.Name),
-			Handler:                      fn.Handler,
-			RegisterWithDiscoveryService: fn.RegisterWithDiscoveryService,
-		})
-	}
-
-	// Validate
-	for _, fn := range myFuncs {
-		if err := fn.validate(names); err != nil {
-			return err
-		}
-	}
-
-	o := defaultOptions
-	for _, opt := range opts {
-		if err := opt(&o); err != nil {
-			return err
-		}
-	}
-
-	f := &funcMgr{
-		ctx: ctx,
-		o:   o,
-		cs:  make([]context.Context, 0, len(myFuncs)),
-		cfs: make([]context.CancelFunc, 0, len(myFuncs)),
-		chs: make([]chan struct{}, 0, len(myFuncs)),
+	r, err := Start(ctx, funcs, opts...)
+	if err != nil {
+		return err
 	}
-
-	if !f.o.noDiscoveryService {
-		f.funcOps.DiscoveryService = NewDiscoveryService()
-	}
-
-	// This context is used to prevent this function from exiting
-	// until a shutdown condition is met.
-	f.exitCtx, f.exitCancel = context.WithCancel(context.Background())
-
-	// Create a new cancellable context, which will handle graceful cancellation of the functions
-	f.shutdownCtx, f.shutdownCancel = context.WithCancel(ctx)
-
-	// Start awaiting on shutdown requests
-	f.startAwaitShutdown()
-
-	// Capture interupts that could trigger shutdown
-	f.startInterruptHandling()
-
-	// Start the functions
-	for _, fn := range myFuncs {
-		f.addFn(fn)
-	}
-
-	f.awaitExit()
-
-	return nil
+	return r.Wait()
 }
 
 type funcMgr struct {
 	ctx            context.Context
 	o              Options
+	log            *slog.Logger
 	funcOps        FunctionOptions
 	lck            sync.Mutex
-	cs             []context.Context
-	cfs            []context.CancelFunc
-	chs            []chan struct{}
+	fns            []*fnEntry
 	exitCtx        context.Context
 	exitCancel     context.CancelFunc
 	shutdownCtx    context.Context
 	shutdownCancel context.CancelFunc
+	rlck           sync.Mutex
+	trigger        *FunctionExit
+	exits          []FunctionExit
+	abandoned      []string
+	stacks         map[string]string
+	started        atomic.Bool                    // Set once the initial StartableFunctions have all been launched
+	pools          map[string]FunctionDeclaration // The replicated FunctionDeclarations, by Name
+	scaleLck       sync.Mutex                     // Serialises changes to the number of replicas
+}
+
+// fnEntry tracks a StartableFunction that has been launched
+type fnEntry struct {
+	name      string
+	cancel    context.CancelFunc
+	done      chan struct{} // Closed once the StartableFunction has exited
+	stopped   atomic.Bool   // Set if the StartableFunction is being stopped, rather than shut down
+	ready     chan struct{} // Closed once the StartableFunction has declared itself ready
+	readyOnce sync.Once
+	deps      []*fnEntry    // The StartableFunctions this StartableFunction depends on
+	priority  int           // The ShutdownPriority of the StartableFunction
+	group     string        // The Name of the replicated FunctionDeclaration, if a replica
+	reload    chan struct{} // Notified when the reload signal is received, if specified
+	timeout   time.Duration // The ShutdownTimeout of the StartableFunction
+}
+
+// exited returns true once the StartableFunction has exited
+func (e *fnEntry) exited() bool {
+	select {
+	case <-e.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// markReady records that the StartableFunction is ready, returning true the first time it is called
+func (e *fnEntry) markReady() (first bool) {
+	e.readyOnce.Do(func() {
+		close(e.ready)
+		first = true
+	})
+	return first
 }
 
 func (f *funcMgr) exit() {
 	f.exitCancel()
 }
 
-func (f *funcMgr) shutdown() {
+// shutdown triggers shutdown, recording the trigger if shutdown has not already begun.
+// If shutdown has already begun without a recorded trigger, then it was due to the
+// external context being Done
+func (f *funcMgr) shutdown(trigger FunctionExit) {
+	f.rlck.Lock()
+	if f.trigger == nil {
+		if f.shutdownCtx.Err() != nil {
+			trigger = FunctionExit{Reason: ExitCancelled}
+		}
+		f.trigger = &trigger
+	}
+	f.rlck.Unlock()
+
 	f.shutdownCancel()
 }
 
+// recordExit records the exit of a StartableFunction
+func (f *funcMgr) recordExit(e FunctionExit) {
+	f.rlck.Lock()
+	defer f.rlck.Unlock()
+
+	f.exits = append(f.exits, e)
+}
+
 func (f *funcMgr) startAwaitShutdown() {
 
+	started := make(chan struct{})
+
 	go func() {
+		close(started)
+
 		<-f.shutdownCtx.Done()
 
-		f.logger("cancelling all contexts")
+		defer f.exit() // Shutdown is complete, or has timed out
 
 		// Gain lock as there is the possibility that addFn() could be
-		// concurrently adding a futher StartableFunction
+		// concurrently adding a futher StartableFunction.  Once released,
+		// no further StartableFunctions can be added.
 		f.lck.Lock()
-		defer f.lck.Unlock()
+		fns := append([]*fnEntry{}, f.fns...)
+		f.lck.Unlock()
+
+		// Contexts are cancelled a tier at a time, waiting for each tier to exit
+		// before moving on to the next, with the Timeout divided between the tiers
+		tiers := shutdownTiers(fns)
+		d := f.o.Timeout / time.Duration(max(len(tiers), 1))
+
+		begin := time.Now()
+
+		// Ensures the trigger is recorded, should the external context have been cancelled
+		f.shutdown(FunctionExit{Reason: ExitCancelled})
+
+		f.rlck.Lock()
+		trigger := *f.trigger
+		f.rlck.Unlock()
+		f.notify(func(o LifecycleObserver) { o.OnShutdownBegin(ShutdownBeginEvent{Trigger: trigger, Time: begin}) })
+
+		f.logger("waiting for Done() from StartableFunction contexts", "shutdown")
+		for i, tier := range tiers {
+			f.logger(fmt.Sprintf("cancelling contexts for shutdown tier %d: %v", i, entryNames(tier)), "shutdown_tier",
+				"tier", i, "functions", entryNames(tier))
+			for _, e := range tier {
+				e.cancel()
+			}
+
+			if overran := awaitTier(tier, d); len(overran) > 0 {
+				f.logger(fmt.Sprintf("timed out waiting for Done() from shutdown tier %d: %v", i, entryNames(overran)), "shutdown_tier_timeout",
+					"tier", i, "functions", entryNames(overran))
+			}
+		}
+
+		// Any StartableFunctions that have still not exited are abandoned
+		var abandoned []string
+		for _, e := range fns {
+			select {
+			case <-e.done:
+			default:
+				abandoned = append(abandoned, e.name)
+			}
+		}
 
-		for _, cf := range f.cfs {
-			cf()
+		if abandoned == nil {
+			f.logger("all contexts are Done()", "shutdown_complete", LogKeyDuration, time.Since(begin))
+		} else {
+			f.logger("timed out waiting for Done() from contexts", "shutdown_timeout",
+				LogKeyDuration, time.Since(begin), "abandoned", abandoned)
+			f.notify(func(o LifecycleObserver) {
+				o.OnShutdownTimeout(ShutdownTimeoutEvent{Abandoned: abandoned, Timeout: f.o.Timeout, Time: time.Now()})
+			})
 		}
 
-		f.exit() // Will now start waiting for shutdowns to complete
+		f.funcOps.Metrics.ObserveDuration(MetricShutdownDuration, time.Since(begin))
+
+		if f.o.StackDump && abandoned != nil {
+			f.dumpStacks(fmt.Sprintf("shutdown timed out, abandoning %v", abandoned))
+		}
+
+		var stacks map[string]string
+		if f.o.AbandonedStacks && abandoned != nil {
+			stacks = make(map[string]string, len(abandoned))
+			for _, name := range abandoned {
+				stacks[name] = goroutineStacks(PprofLabelFunction, name)
+				f.logPanic(fmt.Errorf("StartableFunction %s abandoned: %w", name, ErrShutdownTimeout), "abandoned",
+					LogKeyFunction, name, LogKeyStack, stacks[name])
+			}
+		}
+
+		f.rlck.Lock()
+		f.abandoned = abandoned
+		f.stacks = stacks
+		f.rlck.Unlock()
 	}()
 
-	f.pause()
+	<-started
 }
 
-func (f *funcMgr) startInterruptHandling() {
-	// Trap interrupts
+// osExit allows forced exits to be intercepted when testing
+var osExit = os.Exit
+
+func (f *funcMgr) startSignalHandling() {
+	sigs := append([]os.Signal{}, f.o.Signals...)
+	if f.o.ReloadSignal != nil {
+		sigs = append(sigs, f.o.ReloadSignal)
+	}
+	if f.o.StackDump {
+		sigs = append(sigs, syscall.SIGQUIT)
+	}
+	if len(sigs) == 0 {
+		return
+	}
+
+	// Trap signals
 	signalChan := make(chan os.Signal, 1)
-	signal.Notify(signalChan, os.Interrupt)
+	signal.Notify(signalChan, sigs...)
+
+	started := make(chan struct{})
 
-	// Exits either when interrupt is detected, or when told to shutdown
+	// Exits once shutdown is complete, so that a further signal during shutdown can force exit
 	go func() {
 		defer signal.Stop(signalChan)
 
-		select {
-		case <-signalChan:
-			f.logger("received interrupt")
-			f.shutdown() // Trigger shutdowns
-		case <-f.shutdownCtx.Done():
-			// Requested to shutdown as well
+		close(started)
+
+		signalled := false // Set once a shutdown signal has been received
+
+		for {
+			select {
+			case sig := <-signalChan:
+				switch {
+				case sig == syscall.SIGQUIT && f.o.StackDump:
+					f.dumpStacks(fmt.Sprintf("received signal %v", sig))
+				case sig == f.o.ReloadSignal:
+					if f.shutdownCtx.Err() == nil {
+						f.logger(fmt.Sprintf("received reload signal %v", sig), "reload", "signal", sig.String())
+						f.reload()
+					}
+				case signalled:
+					f.logPanic(fmt.Errorf("received signal %v during shutdown, forcing exit", sig), "forced_exit", "signal", sig.String())
+					osExit(1)
+					return
+				default:
+					// Shutdown may already be in progress for another reason, so only a further signal forces exit
+					signalled = true
+					f.logger(fmt.Sprintf("received signal %v", sig), "signal", "signal", sig.String())
+					f.shutdown(FunctionExit{Reason: ExitInterrupted}) // Trigger shutdowns
+				}
+			case <-f.exitCtx.Done():
+				return
+			}
 		}
 	}()
 
-	f.pause()
+	<-started
+}
+
+// reload notifies each StartableFunction that the reload signal has been received,
+// without blocking if a previous notification is still pending
+func (f *funcMgr) reload() {
+	f.lck.Lock()
+	defer f.lck.Unlock()
+
+	for _, e := range f.fns {
+		select {
+		case e.reload <- struct{}{}:
+		default:
+		}
+	}
 }
 
 // Wrapper ensures graceful launch and shutdown, recovering from unhandled panics from functions
+// and restarting them in place if their RestartPolicy allows.
+// Wrapper returns once the StartableFunction is about to be executed, after registration with the
+// DiscoveryService and listening have started, so that StartableFunctions are launched in order,
+// although their execution may then interleave.
+// If the StartableFunction has dependencies, Wrapper returns once it begins waiting for them.
 // Note this doesn't deal with all unhandled panics: if functions start further goroutines
-// which then panic, that scenario is uncontrolled
-func (f *funcMgr) fWrapper(ctx context.Context, ctxCancel context.CancelFunc, ch chan struct{}, fn FunctionDeclaration) {
+// which then panic, that scenario is uncontrolled unless they were started using FunctionOptions.Go
+func (f *funcMgr) fWrapper(ctx context.Context, e *fnEntry, fn FunctionDeclaration) {
+
+	started := make(chan struct{})
+	ack := sync.OnceFunc(func() { close(started) })
+
+	go func() {
+		// Goroutines of the StartableFunction can be identified in profiles and stack dumps
+		ctx := withFunctionLabels(ctx, fn.Name)
+
+		exit := FunctionExit{Name: fn.Name}
+		attrs := []any{LogKeyFunction, fn.Name}
+		launched := time.Now()
+
+		f.notify(func(o LifecycleObserver) {
+			o.OnStarting(StartingEvent{Name: fn.Name, DependsOn: fn.DependsOn, Time: launched})
+		})
+
+		ready := func() {
+			if e.markReady() {
+				f.logger(fmt.Sprintf("StartableFunction %s is ready", fn.Name), "ready", attrs...)
+				f.notify(func(o LifecycleObserver) { o.OnReady(ReadyEvent{Name: fn.Name, Time: time.Now()}) })
+			}
+		}
 
-	inner := func(ctx context.Context, fn *FunctionDeclaration) (err error) {
-		defer ctxCancel() // Order ensures the supplied ctx is aways cancelled when fn.Func() exits
 		defer func() {
-			ch <- struct{}{}
+			// Ensures acknowledgement, even if the StartableFunction is never executed.  This must precede
+			// shutdownIfIdle, which needs the lock that addFn holds until acknowledgement
+			ack()
+
+			// Cancel the cancellable context, triggering shutdown, unless the StartableFunction
+			// was deliberately stopped, or its ExitBehaviour indicates otherwise
+			switch {
+			case e.stopped.Load():
+			case fn.ExitBehaviour.escalates(exit.Err):
+				f.shutdown(exit)
+			default:
+				f.shutdownIfIdle()
+			}
 		}()
 		defer func() {
-			if r := recover(); r != nil {
-				err = fmt.Errorf("caught unhandled panic in (%s): %v", runtime.FuncForPC(reflect.ValueOf(fn.Func).Pointer()).Name(), r)
+			// Returning without error is considered ready, so that dependents of a one-off initialiser can start
+			if exit.Reason == ExitReturned {
+				ready()
 			}
+			close(e.done)
+		}()
+		defer func() {
+			f.recordExit(exit)
+			f.notify(func(o LifecycleObserver) {
+				o.OnExit(ExitEvent{Exit: exit, Duration: time.Since(launched), Time: time.Now()})
+			})
 		}()
+		defer e.cancel() // Order ensures the supplied ctx is aways cancelled when fn.Func() exits
+
+		// Only start once the StartableFunctions depended upon are ready, which
+		// may take some time, so acknowledge before waiting
+		if len(e.deps) > 0 {
+			ack()
+		}
+
+		if err := f.awaitDependencies(ctx, &fn, e.deps); err != nil {
+			if ctx.Err() != nil {
+				exit.Reason = ExitCancelled
+				return
+			}
+			f.logPanic(err, "dependency_failed", attrs...)
+			exit.Reason = ExitFailed
+			exit.Err = err
+			return
+		}
 
 		// Set up funcOps specific to this StartableFunction, from defaults
-		var funcOps = f.funcOps
-		funcOps.Self = fn.Name
-
-		// If DiscoveryService is running then can register the StartableFunction if requested
-		// either directly via the RegisterWithDiscoveryService flag, or indirectly by the
-		// presence of a Handler
-		if funcOps.DiscoveryService != nil {
-			if fn.RegisterWithDiscoveryService || fn.Handler != nil {
-				identity, err := CreateAndRegisterID(funcOps.DiscoveryService, funcOps.Self, time.Minute, fn.Handler)
+		funcOps := f.prepareFunctionOptions(&fn)
+		if registers(&funcOps, &fn) {
+			attrs = append(attrs, LogKeyID, fn.Name)
+		}
+		attrs = slices.Clip(attrs) // Appending further attributes must not share the backing array
+		funcOps.Logger = f.log.With(attrs...)
+		if e.reload != nil {
+			funcOps.Reload = e.reload
+		}
+		funcOps.ready = ready
+
+		rt := newRestartTracker(fn.RestartPolicy)
+		for run := 1; ; run++ {
+			// Each run receives a fresh context, so that a restart is unaffected by its predecessor,
+			// and a fresh registration with the DiscoveryService, which is removed once the run exits
+			runCtx, runCancel := context.WithCancel(ctx)
+			if err := f.register(runCtx, &fn, &funcOps); err != nil {
+				runCancel()
+				f.logPanic(err, "registration_failed", attrs...)
+				exit.Reason = ExitFailed
+				exit.Err = err
+				return
+			}
+
+			ack()
+
+			f.notify(func(o LifecycleObserver) {
+				o.OnStarted(StartedEvent{Name: fn.Name, Identity: funcOps.Identity, Run: run, Time: time.Now()})
+			})
+
+			err := f.run(runCtx, &fn, funcOps, attrs)
+			cancelled := runCtx.Err() != nil
+			f.deregister(&funcOps, attrs)
+			runCancel()
+
+			// Returning the error of a cancelled context, as is idiomatic, is not a failure
+			if cancelled && isCancellation(err) {
+				err = nil
+			}
+
+			var pe *PanicError
+			exit.Err = err
+			switch {
+			case errors.As(err, &pe):
+				f.logPanic(err, "panicked", attrs...)
+				f.notify(func(o LifecycleObserver) { o.OnPanic(PanicEvent{Name: fn.Name, Err: pe, Time: time.Now()}) })
+				exit.Reason = ExitPanicked
+			case err != nil:
+				f.logPanic(fmt.Errorf("StartableFunction %s returned error: %w", fn.Name, err), "failed", attrs...)
+				exit.Reason = ExitFailed
+			case cancelled:
+				exit.Reason = ExitCancelled
+			default:
+				exit.Reason = ExitReturned
+			}
+
+			// No restarts once shutdown has begun
+			if ctx.Err() != nil || f.shutdownCtx.Err() != nil || !fn.RestartPolicy.shouldRestart(err) {
+				return
+			}
+
+			d, ok := rt.next(time.Now())
+			if !ok {
+				exit.Err = fmt.Errorf("%w for StartableFunction %s", ErrRestartLimitReached, fn.Name)
 				if err != nil {
-					return err
+					exit.Err = fmt.Errorf("%w: %w", exit.Err, err)
 				}
-				funcOps.Identity = identity
+				f.logPanic(exit.Err, "restart_limit_reached", attrs...)
+				return
 			}
 
-			// Wait for Connection requests and handle them, until context is Done
-			if fn.Handler != nil {
-				go func(ctx context.Context, identity Identity) {
-					defer f.logger(fmt.Sprintf("listening ended for %s", identity.ID()))
+			f.logger(fmt.Sprintf("restarting StartableFunction %s in %v", fn.Name, d), "restarting",
+				append(attrs, LogKeyDuration, d)...)
+			f.notify(func(o LifecycleObserver) {
+				o.OnRestart(RestartEvent{Name: fn.Name, Restart: run, Backoff: d, Err: err, Time: time.Now()})
+			})
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(d):
+			}
+		}
+	}()
+
+	<-started
+}
 
-					f.logger(fmt.Sprintf("listening started for %s", identity.ID()))
-					identity.Accept(ctx)
-				}(ctx, funcOps.Identity)
+// shutdownIfIdle triggers shutdown if none of the StartableFunctions remain running,
+// once the initial StartableFunctions have all been launched.  The most recent exit
+// is reported as the trigger.  It is not called for StartableFunctions that are stopped,
+// so that a Runtime whose StartableFunctions have all been stopped remains available to Add.
+func (f *funcMgr) shutdownIfIdle() {
+	if !f.started.Load() {
+		return
+	}
 
-				f.pause()
-			}
+	f.lck.Lock()
+	for _, e := range f.fns {
+		select {
+		case <-e.done:
+		default:
+			f.lck.Unlock()
+			return
 		}
+	}
+	f.lck.Unlock()
 
-		f.logger(fmt.Sprintf("executing StartableFunction %s", fn.Name))
-		defer f.logger(fmt.Sprintf("exited StartableFunction %s", fn.Name))
+	f.rlck.Lock()
+	exit := FunctionExit{Reason: ExitReturned}
+	if len(f.exits) > 0 {
+		exit = f.exits[len(f.exits)-1]
+	}
+	f.rlck.Unlock()
+
+	f.shutdown(exit)
+}
 
-		fn.Func(ctx, &funcOps, fn.Args...)
+// awaitDependencies waits until all of the StartableFunctions that fn depends on are ready,
+// returning an error if any of them exit first, or if the StartupTimeout expires
+func (f *funcMgr) awaitDependencies(ctx context.Context, fn *FunctionDeclaration, deps []*fnEntry) error {
+	if len(deps) == 0 {
 		return nil
 	}
 
-	go func() {
-		defer f.shutdown() // Always cancel the cancellable context, triggering shutdown
+	f.logger(fmt.Sprintf("StartableFunction %s waiting for dependencies %v", fn.Name, fn.DependsOn), "waiting",
+		LogKeyFunction, fn.Name, "dependencies", fn.DependsOn)
+
+	timeout := time.NewTimer(f.o.StartupTimeout)
+	defer timeout.Stop()
 
-		err := inner(ctx, &fn)
-		if err != nil {
-			f.logPanic(err)
+	for _, d := range deps {
+		select {
+		case <-d.ready:
+		case <-d.done:
+			select {
+			case <-d.ready:
+			default:
+				return fmt.Errorf("%w: %s depends on %s", ErrDependencyExited, fn.Name, d.name)
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timeout.C:
+			return fmt.Errorf("%w: %s depends on %s", ErrStartupTimeout, fn.Name, d.name)
 		}
+	}
+
+	return nil
+}
+
+// prepareFunctionOptions creates the FunctionOptions for the StartableFunction from the defaults
+func (f *funcMgr) prepareFunctionOptions(fn *FunctionDeclaration) FunctionOptions {
+	var funcOps = f.funcOps
+	funcOps.Self = fn.Name
+	funcOps.Group = fn.group
+	return funcOps
+}
+
+// register registers the StartableFunction with the DiscoveryService, if requested, setting its Identity.
+// If it has a Handler then it listens for Connection requests until the context is Done
+func (f *funcMgr) register(ctx context.Context, fn *FunctionDeclaration, funcOps *FunctionOptions) error {
+	id, err := registerFunction(funcOps, fn)
+	if err != nil {
+		return err
+	}
+	funcOps.Identity = id
+
+	if fn.Handler != nil && id != nil {
+		listening := make(chan struct{})
+		ack := sync.OnceFunc(func() { close(listening) })
+
+		go func(ctx context.Context, id Identity) {
+			defer ack()
+			defer f.logger(fmt.Sprintf("listening ended for %s", id.ID()), "listening_ended", LogKeyFunction, fn.Name, LogKeyID, id.ID())
+
+			f.logger(fmt.Sprintf("listening started for %s", id.ID()), "listening_started", LogKeyFunction, fn.Name, LogKeyID, id.ID())
+			if i, ok := id.(*identity); ok {
+				i.accept(ctx, ack)
+			} else {
+				ack()
+				id.Accept(ctx)
+			}
+		}(ctx, id)
+
+		<-listening
+	}
+	if !funcOps.heartbeat && id != nil {
+		go renewLease(ctx, funcOps.DiscoveryService, id)
+	}
+
+	return nil
+}
+
+// deregister removes the registration of the StartableFunction with the DiscoveryService, if any,
+// so that it can no longer be found once it has exited
+func (f *funcMgr) deregister(funcOps *FunctionOptions, attrs []any) {
+	if funcOps.Identity == nil {
+		return
+	}
+	switch err := funcOps.DiscoveryService.Deregister(funcOps.Identity.ID()); {
+	case errors.Is(err, ErrIDNotFound):
+		f.logger(fmt.Sprintf("registration of %s had already expired", funcOps.Identity.ID()), "registration_expired", attrs...)
+	case err != nil:
+		f.logPanic(fmt.Errorf("failed to deregister %s: %w", funcOps.Identity.ID(), err), "deregistration_failed", attrs...)
+	}
+	funcOps.Identity = nil
+}
+
+// run executes the StartableFunction once, with logging
+func (f *funcMgr) run(ctx context.Context, fn *FunctionDeclaration, funcOps FunctionOptions, attrs []any) error {
+	f.logger(fmt.Sprintf("executing StartableFunction %s", fn.Name), "started", attrs...)
+
+	begin := time.Now()
+	defer func() {
+		f.logger(fmt.Sprintf("exited StartableFunction %s", fn.Name), "exited", append(attrs, LogKeyDuration, time.Since(begin))...)
 	}()
 
-	f.pause()
+	return callFunction(ctx, fn, funcOps)
+}
+
+// registerFunction registers the StartableFunction with the DiscoveryService, if one is available,
+// and registration is requested either directly via the RegisterWithDiscoveryService flag, or
+// indirectly by the presence of a Handler.  The Identity is nil if no registration is made.
+func registerFunction(funcOps *FunctionOptions, fn *FunctionDeclaration) (Identity, error) {
+	if !registers(funcOps, fn) {
+		return nil, nil
+	}
+	group := fn.group
+	if len(fn.Service) > 0 {
+		group = fn.Service
+	}
+	return CreateAndRegisterID(funcOps.DiscoveryService, fn.Name, time.Minute, fn.Handler,
+		WithIdentityMetrics(funcOps.Metrics), WithIdentityGroup(group), WithIdentityTTL(funcOps.registrationTTL),
+		WithIdentityMetadata(fn.Metadata))
 }
 
+// registers returns true if the StartableFunction is registered with the DiscoveryService
+func registers(funcOps *FunctionOptions, fn *FunctionDeclaration) bool {
+	return funcOps.DiscoveryService != nil && (fn.RegisterWithDiscoveryService || fn.Handler != nil)
+}
+
+// callFunction executes the StartableFunction or ErrStartableFunction once, returning its error
+// or converting an unhandled panic into a *PanicError, joined with the errors of any goroutines
+// it started using FunctionOptions.Go, once they have all returned.
+// funcOps is passed by value so that changes made by one run are not seen by the next
+func callFunction(ctx context.Context, fn *FunctionDeclaration, funcOps FunctionOptions) (err error) {
+	group := newTaskGroup(ctx)
+	funcOps.group = group
+	ctx = group.ctx
+
+	defer func() {
+		if gerr := group.wait(); gerr != nil {
+			err = errors.Join(err, gerr)
+		}
+	}()
+	defer func() {
+		if r := recover(); r != nil {
+			var v any = fn.Func
+			if fn.ErrFunc != nil {
+				v = fn.ErrFunc
+			}
+			err = &PanicError{
+				Func:  runtime.FuncForPC(reflect.ValueOf(v).Pointer()).Name(),
+				Value: r,
+				Stack: debug.Stack(),
+			}
+		}
+	}()
+
+	if fn.ErrFunc != nil {
+		return fn.ErrFunc(ctx, &funcOps, fn.Args...)
+	}
+
+	fn.Func(ctx, &funcOps, fn.Args...)
+	return nil
+}
+
+// isCancellation returns true if err only reports that a context was cancelled, including
+// when it joins several such errors
+func isCancellation(err error) bool {
+	if u, ok := err.(interface{ Unwrap() []error }); ok {
+		errs := u.Unwrap()
+		for _, e := range errs {
+			if !isCancellation(e) {
+				return false
+			}
+		}
+		return len(errs) > 0
+	}
+	return errors.Is(err, context.Canceled)
+}
+
+// ErrShutdownInProgress is raised if a StartableFunction is added once shutdown has begun
+var ErrShutdownInProgress = errors.New("shutdown in progress")
+
 // addFn creates and stores the scaffolding (contexts, chans etc.) needed to manage
 // the lifetime of the provided StartableFunction, ensuring that it can close
 // gracefully if it or another StartableFunction exits
-func (f *funcMgr) addFn(fn FunctionDeclaration) {
+func (f *funcMgr) addFn(fn FunctionDeclaration) error {
 
 	f.lck.Lock()
 	defer f.lck.Unlock()
@@ -400,76 +1069,139 @@ func (f *funcMgr) addFn(fn FunctionDeclaration) {
 	// Once lock obtained, only continue if shutdown context is not Done
 	select {
 	case <-f.shutdownCtx.Done():
+		return ErrShutdownInProgress
 	default:
+		if _, ok := f.pools[fn.Name]; ok {
+			return ErrNameAlreadyExists
+		}
+		if e := f.find(fn.Name); e != nil {
+			if !e.exited() {
+				return ErrNameAlreadyExists
+			}
+			// Only running StartableFunctions reserve their names
+			f.fns = slices.DeleteFunc(f.fns, func(x *fnEntry) bool { return x == e })
+		}
+		deps := make([]*fnEntry, 0, len(fn.DependsOn))
+		for _, dep := range fn.DependsOn {
+			if d := f.find(dep); d != nil {
+				deps = append(deps, d)
+				continue
+			}
+			if _, ok := f.pools[dep]; !ok {
+				return fmt.Errorf("%w: %s depends on %s", ErrUnknownDependency, fn.Name, dep)
+			}
+			deps = append(deps, f.replicas(dep)...)
+		}
+
 		c, cf := context.WithCancel(context.Background())
 
-		ch := make(chan struct{}, 1)
-		f.cs = append(f.cs, c)
-		f.cfs = append(f.cfs, cf)
-		f.chs = append(f.chs, ch)
+		e := &fnEntry{
+			name:     fn.Name,
+			cancel:   cf,
+			done:     make(chan struct{}),
+			ready:    make(chan struct{}),
+			deps:     deps,
+			priority: fn.ShutdownPriority,
+			group:    fn.group,
+			timeout:  fn.ShutdownTimeout,
+		}
+		if f.o.ReloadSignal != nil {
+			e.reload = make(chan struct{}, 1)
+		}
+		f.fns = append(f.fns, e)
 
-		f.fWrapper(c, cf, ch, fn)
+		f.fWrapper(c, e, fn)
+		return nil
 	}
 }
 
-// awaitExit allows for graceful shutdown with optional timeout.
-// There are only possible two scenarios:
-// - A StartableFunction has exited, triggering shutdown of any/all others
-// - The external context is Done(), which means all StartableFunctions should shut down
-func (f *funcMgr) awaitExit() {
-
-	select {
-	case <-f.exitCtx.Done():
-		// Wait for notification to exit
-		f.logger("received Done() for exit context")
-	case <-f.ctx.Done():
-		// External context is Done, so attempt close down
-		f.logger("received Done() for external context")
-		f.shutdown()
+// find returns the fnEntry of the named StartableFunction, or nil if not found.
+// The caller must hold the lock
+func (f *funcMgr) find(name string) *fnEntry {
+	for _, e := range f.fns {
+		if e.name == name {
+			return e
+		}
 	}
+	return nil
+}
 
-	ch := make(chan struct{})
-	defer close(ch)
+// ErrFunctionNotFound is raised if the named StartableFunction is not running
+var ErrFunctionNotFound = errors.New("function not found")
 
-	// In shutdown sequence, each function's inner() will push a struct{}{} to notify that it has exited
-	// So exit will be signalled to ch, only once all functions have exited
-	go func() {
-		defer func() {
-			ch <- struct{}{}
-		}()
+// stopFn cancels the context of the named StartableFunction without triggering shutdown,
+// waiting up to the Timeout for it to exit.  Once exited, it is forgotten, so that the name can be reused,
+// even if it only exits after the Timeout.  Its registration with the DiscoveryService is removed as it exits.
+func (f *funcMgr) stopFn(name string) error {
 
-		f.lck.Lock()
-		defer f.lck.Unlock()
+	f.lck.Lock()
+	e := f.find(name)
+	if e != nil {
+		e.stopped.Store(true)
+	}
+	f.lck.Unlock()
 
-		for _, c := range f.chs {
-			<-c
-		}
-	}()
+	if e == nil {
+		return ErrFunctionNotFound
+	}
 
-	f.pause()
+	f.logger(fmt.Sprintf("stopping StartableFunction %s", name), "stopping", LogKeyFunction, name)
+	e.cancel()
 
-	f.logger("waiting for Done() from StartableFunction contexts")
 	select {
-	case <-ch:
-		f.logger("all contexts are Done()")
-	case <-time.After(f.o.Timeout):
-		f.logger("timed out waiting for Done() from contexts")
+	case <-e.done:
+	case <-time.After(e.shutdownTimeout(f.o.Timeout)):
+		// The StartableFunction is still forgotten, but only once it eventually exits
+		go func() {
+			f.forget(e)
+			f.logger(fmt.Sprintf("StartableFunction %s exited after its stop timed out", name), "stopped_late", LogKeyFunction, name)
+		}()
+		return fmt.Errorf("%s: %w", name, ErrShutdownTimeout)
 	}
 
+	f.forget(e)
+	return nil
 }
 
-func (f *funcMgr) pause() {
-	<-time.After(f.o.PauseDuration)
+// forget removes the fnEntry once the StartableFunction has exited, so that its name can be reused
+func (f *funcMgr) forget(e *fnEntry) {
+	<-e.done
+
+	f.lck.Lock()
+	defer f.lck.Unlock()
+
+	f.fns = slices.DeleteFunc(f.fns, func(x *fnEntry) bool { return x == e })
 }
 
-func (f *funcMgr) logger(s string) {
-	if f.o.Logger != nil && !f.o.ReportPanicsOnly {
-		f.o.Logger.Println(s)
+// awaitExit allows for graceful shutdown with optional timeout, returning an ExitReport
+// describing how the StartableFunctions exited.
+// There are only possible two scenarios:
+// - A StartableFunction has exited, triggering shutdown of any/all others
+// - The external context is Done(), which means all StartableFunctions should shut down
+func (f *funcMgr) awaitExit() *ExitReport {
+
+	select {
+	case <-f.exitCtx.Done():
+		// Wait for notification to exit
+		f.logger("received Done() for exit context", "exit")
+	case <-f.ctx.Done():
+		// External context is Done, so attempt close down
+		f.logger("received Done() for external context", "cancelled")
 	}
-}
 
-func (f *funcMgr) logPanic(err error) {
-	if f.o.Logger != nil {
-		f.o.Logger.Println(err)
+	// Records the external context as the trigger, if shutdown was not triggered otherwise
+	f.shutdown(FunctionExit{Reason: ExitCancelled})
+
+	// Wait for the phased shutdown of the StartableFunctions to complete
+	<-f.exitCtx.Done()
+
+	f.rlck.Lock()
+	defer f.rlck.Unlock()
+
+	return &ExitReport{
+		Trigger:   *f.trigger,
+		Exits:     append([]FunctionExit{}, f.exits...),
+		Abandoned: f.abandoned,
+		Stacks:    f.stacks,
 	}
 }
@@ -3,6 +3,7 @@ package startup
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"testing"
@@ -200,10 +201,10 @@ func TestStartNamedFunctions_4(t *testing.T) {
 
 func TestStartNamedFunctions_5(t *testing.T) {
 
-	var buf bytes.Buffer
+	var buf syncBuffer
 
 	myFunc := func(ctx context.Context, opts *FunctionOptions, args ...any) {
-		buf.WriteString(opts.Self)
+		buf.Write([]byte(opts.Self))
 		<-time.After(10 * time.Millisecond)
 	}
 
@@ -218,9 +219,9 @@ func TestStartNamedFunctions_5(t *testing.T) {
 		},
 	})
 
-	s := buf.String()
-	if s != "FooBar" {
-		t.Fatalf("expected 'FooBar', got: %s", s)
+	// Foo is launched before Bar, but nothing orders their execution once launched
+	if s := buf.String(); s != "FooBar" && s != "BarFoo" {
+		t.Fatalf("expected both Foo and Bar to run, got: %s", s)
 	}
 }
 
@@ -243,3 +244,246 @@ func TestStartNamedFunctions_6(t *testing.T) {
 		t.Fatal("expected unique name to be autogenerated, but got empty name")
 	}
 }
+
+func TestStartNamedFunctions_ExitReport(t *testing.T) {
+
+	boom := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		panic("Boom!")
+	}
+	waiter := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		<-ctx.Done()
+	}
+
+	err := StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{Name: "Waiter", Func: waiter},
+		{Name: "Boom", Func: boom},
+	})
+
+	var report *ExitReport
+	if !errors.As(err, &report) {
+		t.Fatalf("expected *ExitReport, got: %v", err)
+	}
+	if report.Trigger.Name != "Boom" || report.Trigger.Reason != ExitPanicked {
+		t.Fatalf("expected trigger to be Boom panicking, got: %s %s", report.Trigger.Name, report.Trigger.Reason)
+	}
+
+	var pe *PanicError
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected *PanicError, got: %v", err)
+	}
+	if pe.Value != "Boom!" || len(pe.Stack) == 0 {
+		t.Fatalf("expected panic value and stack, got: %v, %d bytes of stack", pe.Value, len(pe.Stack))
+	}
+
+	if len(report.Exits) != 2 {
+		t.Fatalf("expected 2 exits, got: %d", len(report.Exits))
+	}
+	for _, e := range report.Exits {
+		if e.Name == "Waiter" && e.Reason != ExitCancelled {
+			t.Fatalf("expected Waiter to be cancelled, got: %s", e.Reason)
+		}
+	}
+}
+
+func TestStartNamedFunctions_ExitReport_Timeout(t *testing.T) {
+
+	release := make(chan struct{})
+	defer close(release)
+
+	stubborn := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		<-release
+	}
+	short := func(ctx context.Context, opts *FunctionOptions, args ...any) {}
+
+	err := StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{Name: "Stubborn", Func: stubborn},
+		{Name: "Short", Func: short},
+	}, WithTimeout(20*time.Millisecond))
+
+	if !errors.Is(err, ErrShutdownTimeout) {
+		t.Fatalf("Expected error: ErrShutdownTimeout, got: %v", err)
+	}
+
+	report := err.(*ExitReport)
+	if report.Trigger.Name != "Short" || report.Trigger.Reason != ExitReturned {
+		t.Fatalf("expected trigger to be Short returning, got: %s %s", report.Trigger.Name, report.Trigger.Reason)
+	}
+	if len(report.Abandoned) != 1 || report.Abandoned[0] != "Stubborn" {
+		t.Fatalf("expected Stubborn to be abandoned, got: %v", report.Abandoned)
+	}
+}
+
+func TestStartNamedFunctions_ExitReport_Cancelled(t *testing.T) {
+
+	myFunc := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		<-ctx.Done()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := StartNamedFunctions(ctx, []FunctionDeclaration{
+		{Func: myFunc},
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestStartNamedFunctions_ErrFunc(t *testing.T) {
+
+	errFailed := errors.New("failed")
+
+	failing := func(ctx context.Context, opts *FunctionOptions, args ...any) error {
+		return errFailed
+	}
+
+	err := StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{Name: "Failing", ErrFunc: failing},
+	})
+
+	if !errors.Is(err, errFailed) {
+		t.Fatalf("Expected error: errFailed, got: %v", err)
+	}
+	if report := err.(*ExitReport); report.Trigger.Name != "Failing" || report.Trigger.Reason != ExitFailed {
+		t.Fatalf("expected trigger to be Failing failing, got: %s %s", report.Trigger.Name, report.Trigger.Reason)
+	}
+}
+
+func TestStartNamedFunctions_ErrFunc_Cancelled(t *testing.T) {
+
+	waiting := func(ctx context.Context, opts *FunctionOptions, args ...any) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	r, err := Start(ctx, []FunctionDeclaration{
+		{Name: "Waiting", ErrFunc: waiting},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cancel()
+
+	if err := r.Wait(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if exits := r.Report().Exits; len(exits) != 1 || exits[0].Reason != ExitCancelled || exits[0].Err != nil {
+		t.Fatalf("expected Waiting to be cancelled without error, got: %v", exits)
+	}
+}
+
+func TestStartNamedFunctions_ErrFunc_Ambiguous(t *testing.T) {
+
+	err := StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{
+			Func:    func(ctx context.Context, opts *FunctionOptions, args ...any) {},
+			ErrFunc: func(ctx context.Context, opts *FunctionOptions, args ...any) error { return nil },
+		},
+	})
+
+	if err != ErrAmbiguousFunc {
+		t.Fatalf("Expected error: ErrAmbiguousFunc, got: %v", err)
+	}
+}
+
+func TestWithPauseDuration_Deprecated(t *testing.T) {
+
+	myFunc := func(ctx context.Context, opts *FunctionOptions, args ...any) {}
+
+	err := StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{Func: myFunc},
+	}, WithPauseDuration(0))
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestStartNamedFunctions_ExitBehaviour(t *testing.T) {
+
+	var served bool
+
+	warmer := func(ctx context.Context, opts *FunctionOptions, args ...any) {}
+	server := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		served = true
+		<-ctx.Done()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	r, err := Start(ctx, []FunctionDeclaration{
+		{Name: "Warmer", Func: warmer, ExitBehaviour: ShutdownOnFailure},
+		{Name: "Server", Func: server, DependsOn: []string{"Warmer"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := r.Wait(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !served {
+		t.Fatal("expected Server to start once Warmer returned")
+	}
+	if report := r.Report(); report.Trigger.Name != "" || report.Trigger.Reason != ExitCancelled {
+		t.Fatalf("expected external trigger, got: %s %s", report.Trigger.Name, report.Trigger.Reason)
+	}
+}
+
+func TestStartNamedFunctions_ExitBehaviour_Panic(t *testing.T) {
+
+	failed := make(chan struct{})
+
+	failing := func(ctx context.Context, opts *FunctionOptions, args ...any) error {
+		defer close(failed)
+		return errors.New("failed")
+	}
+	boom := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		<-failed
+		panic("Boom!")
+	}
+	waiter := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		<-ctx.Done()
+	}
+
+	err := StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{Name: "Waiter", Func: waiter},
+		{Name: "Failing", ErrFunc: failing, ExitBehaviour: ShutdownOnPanic},
+		{Name: "Boom", Func: boom, ExitBehaviour: ShutdownOnPanic},
+	})
+
+	if report := err.(*ExitReport); report.Trigger.Name != "Boom" || report.Trigger.Reason != ExitPanicked {
+		t.Fatalf("expected trigger to be Boom panicking, got: %s %s", report.Trigger.Name, report.Trigger.Reason)
+	}
+}
+
+func TestStartNamedFunctions_ExitBehaviour_Idle(t *testing.T) {
+
+	short := func(ctx context.Context, opts *FunctionOptions, args ...any) {}
+
+	err := StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{Name: "A", Func: short, ExitBehaviour: NeverShutdown},
+		{Name: "B", Func: short, ExitBehaviour: NeverShutdown},
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestStartNamedFunctions_ExitBehaviour_Invalid(t *testing.T) {
+
+	err := StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{Func: func(ctx context.Context, opts *FunctionOptions, args ...any) {}, ExitBehaviour: NeverShutdown + 1},
+	})
+
+	if err != ErrInvalidExitBehaviour {
+		t.Fatalf("Expected error: ErrInvalidExitBehaviour, got: %v", err)
+	}
+}
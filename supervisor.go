@@ -0,0 +1,296 @@
+package startup
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// SupervisorStrategy determines which children of a Supervisor are restarted when one of them exits
+type SupervisorStrategy int
+
+const (
+	// OneForOne restarts only the child that exited (default)
+	OneForOne SupervisorStrategy = iota
+	// OneForAll stops all the other children, and then restarts every child
+	OneForAll
+	// RestForOne stops the children declared after the child that exited, and then restarts
+	// those children together with the child that exited
+	RestForOne
+)
+
+// Supervisor runs a group of child FunctionDeclarations, restarting them according to its Strategy
+// when they exit.  Its Run method is an ErrStartableFunction, so a Supervisor can be declared (using
+// ErrFunc) alongside other StartableFunctions, or as the child of another Supervisor, allowing a
+// failure to be contained within the subsystem it occurs in.
+//
+// Whether an exited child is restarted is determined by the Mode of its RestartPolicy, which also
+// provides the backoff to apply and the restart budget for that child.  Should a child exit without
+// being restarted, the Supervisor stops its remaining children and exits, escalating the failure
+// to whatever is running the Supervisor, exactly as an ErrStartableFunction exiting would, unless
+// the ExitBehaviour of the child indicates otherwise.  The Supervisor returns once none of its
+// children remain running.
+//
+// As with any other StartableFunction, each run of a child receives a fresh registration with the
+// DiscoveryService, if requested, which is removed once that run exits.
+type Supervisor struct {
+	// Strategy determines which children are restarted when one exits (default is OneForOne)
+	Strategy SupervisorStrategy
+	// Children are started in the order declared, and must follow the same rules as for StartNamedFunctions,
+	// except that DependsOn is ignored
+	Children []FunctionDeclaration
+	// MaxRestarts limits the total restarts across all children within Window.  If zero, then
+	// only the restart budgets of the individual children apply
+	MaxRestarts int
+	// Window is the period over which MaxRestarts is counted (default is 1 minute)
+	Window time.Duration
+}
+
+// supervisedChild tracks the current run of a child of the Supervisor
+type supervisedChild struct {
+	fn      FunctionDeclaration
+	funcOps FunctionOptions
+	reload  chan struct{}
+	rt      *restartTracker
+	cancel  context.CancelFunc
+	done    chan struct{}
+	gen     int
+	running bool
+}
+
+// childExit notifies the Supervisor that a run of the child at idx has exited
+type childExit struct {
+	idx int
+	gen int
+	err error
+}
+
+// Run is an ErrStartableFunction that starts the children of the Supervisor and supervises them
+// until its context is Done, or until it escalates the exit of a child that is not restarted.
+// Escalation of a failure is by returning an error, so that it can be handled in the same way as
+// the failure of any other ErrStartableFunction.  Args are ignored, as each child is given its own Args.
+func (s *Supervisor) Run(ctx context.Context, opts *FunctionOptions, args ...any) error {
+	if opts == nil {
+		opts = &FunctionOptions{}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	children, err := s.prepare(opts)
+	if err != nil {
+		return err
+	}
+
+	if opts.Reload != nil {
+		go s.forwardReloads(ctx, opts.Reload, children)
+	}
+
+	exits := make(chan childExit)
+
+	start := func(i int) error {
+		c := children[i]
+		runCtx, runCancel := context.WithCancel(ctx)
+
+		// Each run is registered afresh, listening and renewing its lease only until the run exits
+		funcOps := c.funcOps
+		identity, err := registerFunction(&funcOps, &c.fn)
+		if err != nil {
+			runCancel()
+			return err
+		}
+		funcOps.Identity = identity
+		if c.fn.Handler != nil && identity != nil {
+			go identity.Accept(runCtx)
+		}
+		if !funcOps.heartbeat && identity != nil {
+			go renewLease(runCtx, funcOps.DiscoveryService, identity)
+		}
+
+		c.gen++
+		c.cancel = runCancel
+		c.done = make(chan struct{})
+		c.running = true
+
+		go func(gen int, done chan struct{}) {
+			runCtx := withFunctionLabels(runCtx, c.fn.Name)
+
+			err := callFunction(runCtx, &c.fn, funcOps)
+			if identity != nil {
+				funcOps.DiscoveryService.Deregister(identity.ID())
+			}
+			runCancel()
+			close(done)
+
+			select {
+			case exits <- childExit{idx: i, gen: gen, err: err}:
+			case <-ctx.Done():
+			}
+		}(c.gen, c.done)
+
+		return nil
+	}
+
+	stop := func(i int) {
+		c := children[i]
+		if c.running {
+			c.cancel()
+			<-c.done
+			c.running = false
+		}
+	}
+
+	// Children are always stopped before the Supervisor exits, in reverse order of declaration
+	defer func() {
+		for i := len(children) - 1; i >= 0; i-- {
+			stop(i)
+		}
+	}()
+
+	for i := range children {
+		if err := start(i); err != nil {
+			return err
+		}
+	}
+
+	var group *restartTracker
+	if s.MaxRestarts > 0 {
+		group = newRestartTracker(RestartPolicy{MaxRestarts: s.MaxRestarts, Window: s.Window})
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e := <-exits:
+			c := children[e.idx]
+			if e.gen != c.gen || !c.running {
+				continue // This run was stopped by the Supervisor
+			}
+			c.running = false
+
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			if !c.fn.RestartPolicy.shouldRestart(e.err) {
+				if !c.fn.ExitBehaviour.escalates(e.err) {
+					if !slices.ContainsFunc(children, func(c *supervisedChild) bool { return c.running }) {
+						return nil
+					}
+					continue
+				}
+				if e.err != nil {
+					return fmt.Errorf("supervised function %s exited: %w", c.fn.Name, e.err)
+				}
+				return nil
+			}
+
+			now := time.Now()
+			d, ok := c.rt.next(now)
+			if !ok {
+				return fmt.Errorf("%w for supervised function %s", ErrRestartLimitReached, c.fn.Name)
+			}
+			if group != nil {
+				if _, ok := group.next(now); !ok {
+					return fmt.Errorf("%w for supervisor %s", ErrRestartLimitReached, opts.Self)
+				}
+			}
+
+			affected := s.affected(e.idx, len(children))
+			for j := len(affected) - 1; j >= 0; j-- {
+				stop(affected[j])
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(d):
+			}
+
+			for _, j := range affected {
+				if err := start(j); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// forwardReloads passes each reload notification received by the Supervisor on to all of its children
+func (s *Supervisor) forwardReloads(ctx context.Context, reload <-chan struct{}, children []*supervisedChild) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-reload:
+			for _, c := range children {
+				select {
+				case c.reload <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}
+}
+
+// affected returns the indices of the children to be restarted, in order of declaration,
+// following the exit of the child at idx
+func (s *Supervisor) affected(idx, n int) []int {
+	first, last := idx, idx
+	switch s.Strategy {
+	case OneForAll:
+		first, last = 0, n-1
+	case RestForOne:
+		last = n - 1
+	}
+
+	affected := make([]int, 0, last-first+1)
+	for i := first; i <= last; i++ {
+		affected = append(affected, i)
+	}
+	return affected
+}
+
+// prepare validates the children of the Supervisor and creates their FunctionOptions
+func (s *Supervisor) prepare(opts *FunctionOptions) ([]*supervisedChild, error) {
+	if len(s.Children) == 0 {
+		return nil, ErrMissingStartableFunctions
+	}
+
+	names := make(map[string]bool, len(s.Children))
+
+	fns := make([]FunctionDeclaration, 0, len(s.Children))
+	for _, fn := range s.Children {
+		fn = fn.withDefaults()
+		if err := fn.validate(names); err != nil {
+			return nil, err
+		}
+		fns = append(fns, fn)
+	}
+
+	children := make([]*supervisedChild, 0, len(fns))
+	for _, fn := range fns {
+		funcOps := *opts
+		funcOps.Self = fn.Name
+		funcOps.Identity = nil
+		if opts.Logger != nil {
+			funcOps.Logger = opts.Logger.With("child", fn.Name)
+		}
+
+		c := &supervisedChild{
+			fn: fn,
+			rt: newRestartTracker(fn.RestartPolicy),
+		}
+		if opts.Reload != nil {
+			c.reload = make(chan struct{}, 1)
+			funcOps.Reload = c.reload
+		}
+		c.funcOps = funcOps
+
+		children = append(children, c)
+	}
+
+	return children, nil
+}
@@ -0,0 +1,267 @@
+package startup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func ExampleSupervisor() {
+
+	var lck sync.Mutex
+	var runs = map[string]int{}
+
+	// worker panics on its first run, and is then restarted by the supervisor
+	worker := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		lck.Lock()
+		runs[opts.Self]++
+		n := runs[opts.Self]
+		lck.Unlock()
+
+		if n == 1 && opts.Self == "Parser" {
+			panic("bad input")
+		}
+		<-ctx.Done()
+	}
+
+	restart := RestartPolicy{Mode: RestartOnPanic, InitialBackoff: time.Millisecond}
+
+	ingestion := &Supervisor{
+		Strategy: OneForOne,
+		Children: []FunctionDeclaration{
+			{Name: "Reader", Func: worker, RestartPolicy: restart},
+			{Name: "Parser", Func: worker, RestartPolicy: restart},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	StartNamedFunctions(ctx, []FunctionDeclaration{
+		{Name: "Ingestion", ErrFunc: ingestion.Run},
+	})
+
+	fmt.Printf("Reader: %d, Parser: %d\n", runs["Reader"], runs["Parser"])
+	// Output:
+	// Reader: 1, Parser: 2
+}
+
+// supervisorRuns runs the Supervisor, failing the child named "B" on its first run,
+// and returns how many times each child was run
+func supervisorRuns(t *testing.T, strategy SupervisorStrategy) map[string]int {
+	t.Helper()
+
+	var lck sync.Mutex
+	var runs = map[string]int{}
+
+	child := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		lck.Lock()
+		runs[opts.Self]++
+		n := runs[opts.Self]
+		lck.Unlock()
+
+		if n == 1 && opts.Self == "B" {
+			panic("Boom!")
+		}
+		<-ctx.Done()
+	}
+
+	restart := RestartPolicy{Mode: RestartOnPanic, InitialBackoff: time.Millisecond}
+
+	s := &Supervisor{
+		Strategy: strategy,
+		Children: []FunctionDeclaration{
+			{Name: "A", Func: child, RestartPolicy: restart},
+			{Name: "B", Func: child, RestartPolicy: restart},
+			{Name: "C", Func: child, RestartPolicy: restart},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	StartNamedFunctions(ctx, []FunctionDeclaration{
+		{ErrFunc: s.Run},
+	})
+
+	lck.Lock()
+	defer lck.Unlock()
+	return runs
+}
+
+func TestSupervisor_Strategies(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		strategy SupervisorStrategy
+		expected map[string]int
+	}{
+		{"OneForOne", OneForOne, map[string]int{"A": 1, "B": 2, "C": 1}},
+		{"OneForAll", OneForAll, map[string]int{"A": 2, "B": 2, "C": 2}},
+		{"RestForOne", RestForOne, map[string]int{"A": 1, "B": 2, "C": 2}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			runs := supervisorRuns(t, test.strategy)
+			for name, n := range test.expected {
+				if runs[name] != n {
+					t.Fatalf("expected %s to run %d times, got: %d", name, n, runs[name])
+				}
+			}
+		})
+	}
+}
+
+func TestSupervisor_Escalation(t *testing.T) {
+
+	var childRuns int
+	var supervisorRuns int
+
+	child := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		childRuns++
+		panic("Boom!")
+	}
+
+	s := &Supervisor{
+		Children: []FunctionDeclaration{
+			{
+				Name: "Child",
+				Func: child,
+				RestartPolicy: RestartPolicy{
+					Mode:           RestartOnPanic,
+					MaxRestarts:    1,
+					InitialBackoff: time.Millisecond,
+				},
+			},
+		},
+	}
+
+	supervisor := func(ctx context.Context, opts *FunctionOptions, args ...any) error {
+		supervisorRuns++
+		return s.Run(ctx, opts, args...)
+	}
+
+	err := StartNamedFunctions(context.Background(), []FunctionDeclaration{
+		{
+			Name:    "Supervisor",
+			ErrFunc: supervisor,
+			RestartPolicy: RestartPolicy{
+				Mode:           RestartOnFailure,
+				MaxRestarts:    1,
+				InitialBackoff: time.Millisecond,
+			},
+		},
+	}, WithTimeout(time.Second))
+
+	if !errors.Is(err, ErrRestartLimitReached) {
+		t.Fatalf("Expected error: ErrRestartLimitReached, got: %v", err)
+	}
+	if supervisorRuns != 2 {
+		t.Fatalf("expected supervisor to be restarted once, got %d runs", supervisorRuns)
+	}
+	if childRuns != 4 {
+		t.Fatalf("expected child to run twice per supervisor run, got %d runs", childRuns)
+	}
+}
+
+func TestSupervisor_NoChildren(t *testing.T) {
+
+	err := (&Supervisor{}).Run(context.Background(), nil)
+
+	if err != ErrMissingStartableFunctions {
+		t.Fatalf("Expected error: ErrMissingStartableFunctions, got: %v", err)
+	}
+}
+
+func TestSupervisor_RegistrationPerRun(t *testing.T) {
+
+	var lck sync.Mutex
+	var identities []Identity
+	found := make(chan error, 1)
+
+	// Worker panics on its first run, and checks it can be found on its second
+	worker := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		lck.Lock()
+		identities = append(identities, opts.Identity)
+		n := len(identities)
+		lck.Unlock()
+
+		if n == 1 {
+			panic("Boom!")
+		}
+		_, err := opts.DiscoveryService.Find(opts.Self)
+		found <- err
+		<-ctx.Done()
+	}
+
+	s := &Supervisor{
+		Children: []FunctionDeclaration{
+			{
+				Name:                         "Worker",
+				Func:                         worker,
+				RegisterWithDiscoveryService: true,
+				RestartPolicy:                RestartPolicy{Mode: RestartOnPanic, InitialBackoff: time.Millisecond},
+			},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	go StartNamedFunctions(ctx, []FunctionDeclaration{
+		{Name: "Supervisor", ErrFunc: s.Run},
+	})
+
+	if err := <-found; err != nil {
+		t.Fatalf("expected the restarted run to be registered, got: %v", err)
+	}
+
+	lck.Lock()
+	defer lck.Unlock()
+	if identities[0] == nil || identities[1] == nil || identities[0] == identities[1] {
+		t.Fatalf("expected each run to receive a fresh Identity, got: %v", identities)
+	}
+}
+
+func TestSupervisor_ExitedChildDeregistered(t *testing.T) {
+
+	handler := func(ctx context.Context, req *Req, res *Res) {
+		res.Status = Success
+	}
+	found := make(chan error, 1)
+
+	// Once exits without being restarted, whilst Watcher waits for its registration to be removed
+	once := func(ctx context.Context, opts *FunctionOptions, args ...any) {}
+	watcher := func(ctx context.Context, opts *FunctionOptions, args ...any) {
+		var err error
+		for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+			if _, err = opts.DiscoveryService.Find("Once"); err != nil {
+				break
+			}
+		}
+		found <- err
+		<-ctx.Done()
+	}
+
+	s := &Supervisor{
+		Children: []FunctionDeclaration{
+			{Name: "Once", Func: once, Handler: handler, ExitBehaviour: NeverShutdown},
+			{Name: "Watcher", Func: watcher},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	go StartNamedFunctions(ctx, []FunctionDeclaration{
+		{Name: "Supervisor", ErrFunc: s.Run},
+	})
+
+	if err := <-found; err != ErrIDNotFound {
+		t.Fatalf("Expected error: ErrIDNotFound, got: %v", err)
+	}
+}
@@ -0,0 +1,248 @@
+package startup
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RegistrationEventType describes the change to a registration with the DiscoveryService
+type RegistrationEventType int
+
+const (
+	// RegistrationAdded is raised when an Identity is registered
+	RegistrationAdded RegistrationEventType = iota
+	// RegistrationRemoved is raised when an Identity is deregistered, or its lease expires
+	RegistrationRemoved
+	// RegistrationUpdated is raised when the registration of an Identity changes, such as when its Metadata is replaced.
+	// Renewing a lease is not reported
+	RegistrationUpdated
+	// RegistrationHealthChanged is raised when the health of an Identity changes
+	RegistrationHealthChanged
+)
+
+func (t RegistrationEventType) String() string {
+	switch t {
+	case RegistrationAdded:
+		return "added"
+	case RegistrationRemoved:
+		return "removed"
+	case RegistrationUpdated:
+		return "updated"
+	case RegistrationHealthChanged:
+		return "health_changed"
+	default:
+		return "unknown"
+	}
+}
+
+// Registration describes an Identity registered with the DiscoveryService
+type Registration struct {
+	// ID is the id of the Identity
+	ID string
+	// Group is the group of the Identity, if any, which is the name of the service it is an instance of
+	Group string
+	// Healthy is true unless the Identity has been marked as unhealthy (see DiscoveryService.SetHealth)
+	Healthy bool
+	// Expires is the time the lease of the registration expires, which is zero if it does not expire
+	Expires time.Time
+	// Metadata describes the Identity
+	Metadata Metadata
+	// Outstanding is the number of requests being handled by the Identity, if it is Loaded
+	Outstanding int
+	// Connectable is true if the Identity has a Location, and so accepts Connection requests
+	Connectable bool
+}
+
+// RegistrationEvent describes a change to a registration with the DiscoveryService
+type RegistrationEvent struct {
+	Type         RegistrationEventType
+	Registration Registration
+	Time         time.Time
+}
+
+// maxWatchQueue is the number of RegistrationEvents that can be queued for a call to Watch
+// whose receiver is not keeping up, after which its chan is closed
+var maxWatchQueue = 1024
+
+// watcher queues the RegistrationEvents for a call to Watch, so that they are delivered
+// in order without blocking changes to the DiscoveryService
+type watcher struct {
+	filter   func(Registration) bool
+	lck      sync.Mutex
+	queue    []queuedEvent
+	overflow bool // Set once the queue is full, after which no further RegistrationEvents are delivered
+	notify   chan struct{}
+}
+
+// queuedEvent is a RegistrationEvent awaiting delivery, with the Registration as it was before
+// the change, if the registration was changed rather than added or removed
+type queuedEvent struct {
+	event RegistrationEvent
+	prev  *Registration
+}
+
+// push queues the RegistrationEvent, unless the queue is full
+func (w *watcher) push(e RegistrationEvent, prev *Registration) {
+	w.lck.Lock()
+	switch {
+	case w.overflow:
+	case len(w.queue) >= maxWatchQueue:
+		w.overflow = true
+		w.queue = nil
+	default:
+		w.queue = append(w.queue, queuedEvent{event: e, prev: prev})
+	}
+	w.lck.Unlock()
+
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+// deliver sends the queued RegistrationEvents that pass the filter to ch until the context is Done,
+// or the queue has overflowed, and then closes ch.  The filter is called without holding the lock
+// of the DiscoveryService, so that it can call the DiscoveryService.
+func (w *watcher) deliver(ctx context.Context, ch chan<- RegistrationEvent) {
+	defer close(ch)
+
+	for {
+		w.lck.Lock()
+		queue, overflow := w.queue, w.overflow
+		w.queue = nil
+		w.lck.Unlock()
+
+		if overflow {
+			return
+		}
+
+		for _, q := range queue {
+			e, ok := w.filtered(q)
+			if !ok {
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case ch <- e:
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.notify:
+		}
+	}
+}
+
+// filtered applies the filter to the queued RegistrationEvent, returning false if it is not to be delivered.
+// A change to a registration that starts or stops passing the filter is delivered as it being added or removed,
+// so that the receiver maintains an accurate view of the registrations that pass the filter.
+func (w *watcher) filtered(q queuedEvent) (RegistrationEvent, bool) {
+	e := q.event
+	if w.filter == nil {
+		return e, true
+	}
+
+	passes := w.filter(e.Registration)
+	if q.prev == nil {
+		return e, passes
+	}
+
+	switch passed := w.filter(*q.prev); {
+	case passed && passes:
+	case passes:
+		e.Type = RegistrationAdded
+	case passed:
+		e.Type = RegistrationRemoved
+	default:
+		return e, false
+	}
+	return e, true
+}
+
+// snapshot returns the Registration describing the registered Identity
+func (r *registration) snapshot() Registration {
+	reg := Registration{
+		ID:          r.id.ID(),
+		Healthy:     r.healthy,
+		Metadata:    r.meta.clone(),
+		Connectable: r.id.Loc() != nil,
+	}
+	if g, ok := r.id.(Grouped); ok {
+		reg.Group = g.Group()
+	}
+	if l, ok := r.id.(Loaded); ok {
+		reg.Outstanding = l.Outstanding()
+	}
+	if r.ttl > 0 {
+		reg.Expires = r.expires
+	}
+	return reg
+}
+
+// publish notifies the watchers of a change to the registration, which prior to the change
+// is described by prev, or nil if the registration has been added or removed.
+// The caller must hold the lock
+func (d *ds) publish(t RegistrationEventType, r *registration, prev *Registration) {
+	if len(d.watchers) == 0 {
+		return
+	}
+
+	e := RegistrationEvent{Type: t, Registration: r.snapshot(), Time: time.Now()}
+	for w := range d.watchers {
+		w.push(e, prev)
+	}
+}
+
+func (d *ds) Watch(ctx context.Context, filter func(Registration) bool) (<-chan RegistrationEvent, error) {
+	w := &watcher{
+		filter: filter,
+		notify: make(chan struct{}, 1),
+	}
+
+	d.lck.Lock()
+	// The current registrations are reported as added, so that the watcher starts with a complete view
+	now := time.Now()
+	for id := range d.m {
+		if r, ok := d.lookup(id); ok {
+			w.push(RegistrationEvent{Type: RegistrationAdded, Registration: r.snapshot(), Time: now}, nil)
+		}
+	}
+	d.watchers[w] = struct{}{}
+	d.lck.Unlock()
+
+	ch := make(chan RegistrationEvent)
+	go func() {
+		w.deliver(ctx, ch)
+
+		d.lck.Lock()
+		defer d.lck.Unlock()
+		delete(d.watchers, w)
+	}()
+
+	return ch, nil
+}
+
+func (d *ds) SetHealth(id string, healthy bool) error {
+	if len(id) == 0 {
+		return ErrInvalidID
+	}
+
+	d.lck.Lock()
+	defer d.lck.Unlock()
+
+	r, ok := d.lookup(id)
+	if !ok {
+		return ErrIDNotFound
+	}
+
+	if r.healthy != healthy {
+		prev := r.snapshot()
+		r.healthy = healthy
+		d.publish(RegistrationHealthChanged, r, &prev)
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package startup
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func ExampleDiscoveryService_Watch() {
+
+	ds := NewDiscoveryService()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, _ := ds.Watch(ctx, nil)
+
+	CreateAndRegisterID(ds, "Bob", time.Minute, nil)
+	ds.SetHealth("Bob", false)
+	ds.Deregister("Bob")
+
+	for range 3 {
+		e := <-events
+		fmt.Println(e.Type, e.Registration.ID, e.Registration.Healthy)
+	}
+	// Output:
+	// added Bob true
+	// health_changed Bob false
+	// removed Bob false
+}
+
+// nextEvent returns the next RegistrationEvent, failing if none is received
+func nextEvent(t *testing.T, events <-chan RegistrationEvent) RegistrationEvent {
+	t.Helper()
+
+	select {
+	case e, ok := <-events:
+		if !ok {
+			t.Fatal("unexpected close of events")
+		}
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for event")
+		return RegistrationEvent{}
+	}
+}
+
+func TestDiscoveryService_Watch(t *testing.T) {
+
+	ds := NewDiscoveryService()
+
+	if _, err := CreateAndRegisterID(ds, "Alice", time.Minute, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := ds.Watch(ctx, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Existing registrations are reported first
+	if e := nextEvent(t, events); e.Type != RegistrationAdded || e.Registration.ID != "Alice" {
+		t.Fatalf("expected Alice to be added, got: %v", e)
+	}
+
+	if _, err := CreateAndRegisterID(ds, "Bob", time.Minute, nil, WithIdentityTTL(time.Minute)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e := nextEvent(t, events)
+	if e.Type != RegistrationAdded || e.Registration.ID != "Bob" || e.Registration.Expires.IsZero() {
+		t.Fatalf("expected Bob to be added with a lease, got: %v", e)
+	}
+	expires := e.Registration.Expires
+
+	ds.SetHealth("Bob", false)
+	ds.SetHealth("Bob", false) // Unchanged, so no event
+	time.Sleep(time.Millisecond)
+	ds.Renew("Bob") // Renewals are not reported
+
+	if e := nextEvent(t, events); e.Type != RegistrationHealthChanged || e.Registration.Healthy {
+		t.Fatalf("expected Bob to be unhealthy, got: %v", e)
+	}
+	if regs := ds.List(); len(regs) != 2 || !regs[1].Expires.After(expires) {
+		t.Fatalf("expected Bob's lease to be renewed, got: %v", regs)
+	}
+
+	ds.Deregister("Alice")
+	if e := nextEvent(t, events); e.Type != RegistrationRemoved || e.Registration.ID != "Alice" {
+		t.Fatalf("expected Alice to be removed, got: %v", e)
+	}
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestDiscoveryService_WatchFilterAndExpiry(t *testing.T) {
+
+	ds := NewDiscoveryService()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := ds.Watch(ctx, func(r Registration) bool { return r.Group == "Consumer" })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	CreateAndRegisterID(ds, "Other", time.Minute, nil)
+	CreateAndRegisterID(ds, "Consumer-0", time.Minute, nil, WithIdentityGroup("Consumer"), WithIdentityTTL(20*time.Millisecond))
+
+	if e := nextEvent(t, events); e.Type != RegistrationAdded || e.Registration.ID != "Consumer-0" {
+		t.Fatalf("expected Consumer-0 to be added, got: %v", e)
+	}
+
+	// The expired lease is reported without the registration being looked up
+	if e := nextEvent(t, events); e.Type != RegistrationRemoved || e.Registration.ID != "Consumer-0" {
+		t.Fatalf("expected Consumer-0 to be removed, got: %v", e)
+	}
+}
+
+func TestDiscoveryService_SetHealth(t *testing.T) {
+
+	ds := NewDiscoveryService()
+
+	if err := ds.SetHealth("Bob", false); err != ErrIDNotFound {
+		t.Fatalf("Expected error: ErrIDNotFound, got: %v", err)
+	}
+	if err := ds.SetHealth("", false); err != ErrInvalidID {
+		t.Fatalf("Expected error: ErrInvalidID, got: %v", err)
+	}
+}
+
+func TestDiscoveryService_WatchOverflow(t *testing.T) {
+
+	defer func(n int) { maxWatchQueue = n }(maxWatchQueue)
+	maxWatchQueue = 2
+
+	ds := NewDiscoveryService()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := ds.Watch(ctx, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Nothing is received, so the queue overflows
+	for i := range 10 {
+		CreateAndRegisterID(ds, fmt.Sprintf("Bob-%d", i), time.Minute, nil)
+	}
+
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("expected events to be closed once the queue overflowed")
+		}
+	}
+}
+
+func TestDiscoveryService_WatchFilterCallsDiscoveryService(t *testing.T) {
+
+	ds := NewDiscoveryService()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The filter is not called whilst the DiscoveryService is locked
+	events, err := ds.Watch(ctx, func(r Registration) bool {
+		_, err := ds.Find(r.ID)
+		return err == nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	CreateAndRegisterID(ds, "Bob", time.Minute, nil)
+
+	if e := nextEvent(t, events); e.Type != RegistrationAdded || e.Registration.ID != "Bob" {
+		t.Fatalf("expected Bob to be added, got: %v", e)
+	}
+}